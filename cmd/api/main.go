@@ -2,19 +2,36 @@ package main
 
 import (
 	_ "REST-service-sub/docs"
+	"REST-service-sub/internal/auth"
 	"REST-service-sub/internal/config"
 	"REST-service-sub/internal/db"
 	"REST-service-sub/internal/handler"
+	"REST-service-sub/internal/health"
 	"REST-service-sub/internal/logger"
+	"REST-service-sub/internal/metrics"
 	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/notify"
+	"REST-service-sub/internal/ratelimit"
+	"REST-service-sub/internal/reload"
+	"REST-service-sub/internal/scheduler"
+	"REST-service-sub/internal/server"
 	"REST-service-sub/internal/service"
-	"fmt"
+	"REST-service-sub/internal/tracing"
+	"REST-service-sub/migrations"
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // @title REST API Subscription service
@@ -25,45 +42,289 @@ import (
 
 func main() {
 	_ = godotenv.Load("../../.env")
-	cfg := config.LoadConfig()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
 
-	logger.Init(cfg.LogLevel)
+	if err := logger.Init(cfg); err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize logger")
+	}
 	log.Info().Str("log_level", cfg.LogLevel).Msg("Logger is initialized")
 
-	fmt.Println("Config loaded successfully...")
+	log.Info().Msg("Config loaded successfully...")
 
+	// gin's mode is set once from the startup level, a reload or PUT /admin/log-level does not change it
 	if strings.ToLower(cfg.LogLevel) == "debug" {
 		gin.SetMode(gin.DebugMode)
 	} else {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// SIGINT and SIGTERM start the graceful shutdown, see server.Serve
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Init(context.Background(), cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize tracing")
+	}
+
 	gdb, err := db.NewGormDB(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
-	fmt.Println("Database connected successfully!")
+	log.Info().Msg("Database connected successfully")
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to get database connection pool")
+	}
+
+	// background workers run until the server is shut down
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workersCtx)
+		}()
+	}
 
 	subService := service.NewSubscriptionService(gdb)
 	subHandler := handler.NewSubscriptionHandler(subService)
+	memberHandler := handler.NewMemberHandler(service.NewMemberService(gdb), subService)
+	webhookHandler := handler.NewWebhookHandler(service.NewWebhookService(gdb))
+	apiKeyService := service.NewAPIKeyService(gdb)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	roleService := service.NewRoleService(gdb)
+	roleHandler := handler.NewRoleHandler(roleService)
+
+	webhookWorker := service.NewWebhookWorker(gdb, service.WebhookWorkerConfig{
+		PollInterval: cfg.WebhookPollInterval,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		BackoffBase:  cfg.WebhookBackoffBase,
+		BackoffMax:   cfg.WebhookBackoffMax,
+		Timeout:      cfg.WebhookTimeout,
+	})
+	runWorker(webhookWorker.Run)
+
+	eventBroker := service.NewEventBroker(gdb)
+	runWorker(func(ctx context.Context) {
+		if err := eventBroker.Listen(ctx, cfg.DSN()); err != nil {
+			log.Error().Err(err).Msg("Subscription event listener stopped")
+		}
+	})
+	eventsHandler := handler.NewEventsHandler(eventBroker)
+
+	budgetService := service.NewBudgetService(gdb, subService)
+	budgetHandler := handler.NewBudgetHandler(budgetService)
+	runWorker(service.NewBudgetWorker(budgetService, eventBroker).Run)
+
+	notifier, err := notify.New(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure notifier")
+	}
+	reminderService := service.NewReminderService(gdb, notifier, cfg.ReminderDaysBefore)
+
+	jobs := scheduler.New()
+	jobs.Add("subscription-expirations", cfg.ExpirySweepInterval, func(ctx context.Context) error {
+		_, err := subService.RecordExpirations(ctx, time.Now())
+		return err
+	})
+	jobs.Add("budget-evaluation", cfg.BudgetEvaluationInterval, func(ctx context.Context) error {
+		return budgetService.EvaluateAll(ctx, time.Now())
+	})
+	jobs.Add("reminders", cfg.ReminderInterval, func(ctx context.Context) error {
+		_, err := reminderService.SendDue(ctx, time.Now())
+		return err
+	})
+
+	// the limiter is built while disabled as well, a reload may turn it on
+	var limiter *ratelimit.Limiter
+	var store ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "postgres":
+		pgStore := ratelimit.NewPostgresStore(gdb)
+		jobs.Add("rate-limit-purge", time.Hour, func(ctx context.Context) error {
+			_, err := pgStore.Purge(ctx, time.Now().Add(-limiter.FullAfter()))
+			return err
+		})
+		store = pgStore
+	case "memory":
+		store = ratelimit.NewMemoryStore()
+	default:
+		log.Fatal().Str("store", cfg.RateLimitStore).Msg("Unknown RATE_LIMIT_STORE")
+	}
+	limiter = ratelimit.New(store, ratelimit.Limit{PerMinute: cfg.RateLimitPerMinute, Burst: cfg.RateLimitBurst}).
+		Class("expensive", ratelimit.Limit{PerMinute: cfg.RateLimitExpensivePerMinute, Burst: cfg.RateLimitExpensiveBurst},
+			"/subscriptions/aggregate", "/subscriptions/search").
+		Class("ip", ratelimit.Limit{PerMinute: cfg.RateLimitIPPerMinute, Burst: cfg.RateLimitIPBurst})
+	limiter.SetEnabled(cfg.RateLimitEnabled)
+
+	var swaggerEnabled atomic.Bool
+	swaggerEnabled.Store(cfg.SwaggerEnabled)
+	cors := middleware.NewCORSPolicy(splitList(cfg.CORSAllowedOrigins), cfg.CORSMaxAge)
+
+	// SIGHUP and POST /admin/reload re-read these settings, the others need a restart
+	settings := reload.New(func() (*config.Config, error) { return config.Load(os.Args[1:]) })
+	settings.OnReload(func(cfg *config.Config) {
+		limiter.SetEnabled(cfg.RateLimitEnabled)
+		limiter.SetLimit(ratelimit.DefaultClass, ratelimit.Limit{PerMinute: cfg.RateLimitPerMinute, Burst: cfg.RateLimitBurst})
+		limiter.SetLimit("expensive", ratelimit.Limit{PerMinute: cfg.RateLimitExpensivePerMinute, Burst: cfg.RateLimitExpensiveBurst})
+		limiter.SetLimit("ip", ratelimit.Limit{PerMinute: cfg.RateLimitIPPerMinute, Burst: cfg.RateLimitIPBurst})
+		swaggerEnabled.Store(cfg.SwaggerEnabled)
+		cors.SetOrigins(splitList(cfg.CORSAllowedOrigins))
+	})
+	runWorker(settings.WatchSignals)
+
+	idempotencyService := service.NewIdempotencyService(gdb)
+	jobs.Add("idempotency-purge", time.Hour, func(ctx context.Context) error {
+		_, err := idempotencyService.Purge(ctx, time.Now())
+		return err
+	})
+
+	if cfg.MetricsEnabled {
+		if err := metrics.RegisterDB(sqlDB, cfg.PostgresDB); err != nil {
+			log.Fatal().Err(err).Msg("Failed to register database metrics")
+		}
+		jobs.Add("metrics-refresh", cfg.MetricsRefreshInterval, func(ctx context.Context) error {
+			stats, err := subService.TenantStats(ctx, time.Now())
+			if err != nil {
+				return err
+			}
+			metrics.SetTenantStats(stats)
+			return nil
+		})
+	}
+
+	jobs.Start(workersCtx)
 
 	r := gin.New()
+	if err := r.SetTrustedProxies(splitList(cfg.TrustedProxies)); err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
 	r.Use(gin.Recovery())
-	r.Use(middleware.RequestLogger())
+	if cfg.TracingExporter != "none" {
+		r.Use(otelgin.Middleware(tracing.ServiceName))
+	}
+	r.Use(middleware.Correlation())
+	if cfg.MetricsEnabled {
+		r.Use(middleware.Metrics())
+	}
+	r.Use(middleware.RequestLogger(cfg.LogRequestSampling))
+	r.Use(middleware.CORS(cors))
 
 	// Swagger init
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	r.GET("/swagger/*any", middleware.Enabled(&swaggerEnabled), ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// Health check
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, "OK")
-	})
+	// Health checks
+	schemaVersion, err := migrations.Latest()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to read migrations")
+	}
+	readiness := health.NewChecker(cfg.HealthCheckTimeout).
+		Add("database", health.Ping(sqlDB)).
+		Add("connection_pool", health.PoolSaturation(sqlDB, cfg.HealthPoolSaturation)).
+		Add("schema", health.SchemaVersion(gdb, schemaVersion))
+	handler.NewHealthHandler(readiness).RegisterRoutes(r)
+
+	if cfg.MetricsEnabled {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	// the SSE stream stays open, it has no deadline
+	timeouts := middleware.NewTimeouts(cfg.QueryTimeout).
+		Route(cfg.QueryAggregateTimeout, "/subscriptions/aggregate", "/subscriptions/search", "/users/:user_id/budget/status").
+		Route(0, "/subscriptions/events")
+	r.Use(middleware.Timeout(timeouts))
+
+	// routes registered from here on require an API key, a bearer token or, with TLS_CLIENT_CERT_ROLES,
+	// a client certificate; without any of them configured the API is open, except the management routes
+	authCfg := auth.Config{
+		Secret:       cfg.JWTSecret,
+		JWKSURL:      cfg.JWTJWKSURL,
+		JWKSFile:     cfg.JWTJWKSFile,
+		JWKSCacheTTL: cfg.JWTJWKSCacheTTL,
+		Issuer:       cfg.JWTIssuer,
+		Audience:     cfg.JWTAudience,
+		AdminClaim:   cfg.JWTAdminClaim,
+		RolesClaim:   cfg.JWTRolesClaim,
+		TenantClaim:  cfg.JWTTenantClaim,
+		Leeway:       cfg.JWTLeeway,
+	}
+	var verifier *auth.Verifier
+	if authCfg.Enabled() {
+		verifier, err = auth.NewVerifier(authCfg)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to configure authentication")
+		}
+	} else {
+		log.Warn().Msg("JWT_SECRET, JWT_JWKS_URL and JWT_JWKS_FILE are not set, bearer authentication is disabled")
+	}
+	certRoles := splitList(cfg.TLSClientCertRoles)
+	for _, role := range certRoles {
+		if !auth.ValidRole(role) {
+			log.Fatal().Str("role", role).Msg("Unknown role in TLS_CLIENT_CERT_ROLES")
+		}
+	}
+	// API keys are issued by authenticated callers, so they are accepted, and anonymous requests
+	// rejected, once bearer tokens or client certificates are configured
+	var keys middleware.APIKeyAuthenticator
+	if verifier != nil || len(certRoles) > 0 {
+		keys = apiKeyService
+	} else {
+		log.Warn().Msg("Neither bearer tokens nor TLS_CLIENT_CERT_ROLES are configured, authentication is disabled")
+	}
+	// requests rejected by Authenticate count as well, guessing credentials is limited per address
+	r.Use(middleware.RateLimitIP(limiter, "ip"))
+	r.Use(middleware.Authenticate(verifier, keys, certRoles...))
+
+	// routes registered from here on are scoped to the tenant of the request
+	r.Use(middleware.Tenant(cfg.TenantHeader, cfg.TenantRequired))
+	r.Use(middleware.Roles(roleService))
+	r.Use(middleware.RateLimit(limiter))
+	r.Use(middleware.Idempotency(idempotencyService, cfg.IdempotencyTTL, "/subscriptions"))
 
 	subHandler.RegisterRoutes(r)
+	memberHandler.RegisterRoutes(r)
+	webhookHandler.RegisterRoutes(r)
+	eventsHandler.RegisterRoutes(r)
+	budgetHandler.RegisterRoutes(r)
+	apiKeyHandler.RegisterRoutes(r)
+	roleHandler.RegisterRoutes(r)
+	handler.NewAdminHandler(settings).RegisterRoutes(r)
+
+	srv, err := server.New(cfg, r)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure server")
+	}
+	srv.BeforeShutdown(readiness.Drain)
+	srv.OnShutdown(eventBroker.Close)
+	serveErr := srv.Run(ctx)
+
+	stopWorkers()
+	jobs.Wait()
+	workers.Wait()
+	if err := shutdownTracing(context.Background()); err != nil {
+		log.Error().Err(err).Msg("Failed to flush traces")
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Error().Err(err).Msg("Failed to close database connection pool")
+	}
+	if serveErr != nil {
+		log.Fatal().Err(serveErr).Msg("Server stopped with an error")
+	}
+	log.Info().Msg("Shutdown complete")
+}
 
-	addr := fmt.Sprintf(":%s", cfg.AppPort)
-	fmt.Printf("Server is listening on %s\n", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatal().Err(err).Msg("Failed to start server")
+// splitList splits a comma separated setting, ignoring blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// APIKeyScopes lists the permissions an API key can be granted. Keys are not bound to a user,
+// their scopes apply to the data of every user of the tenant.
+var APIKeyScopes = []string{
+	PermSubscriptionsRead,
+	PermSubscriptionsWrite,
+	PermAggregateRead,
+	PermHistoryRead,
+	PermBudgetsRead,
+	PermBudgetsWrite,
+}
+
+const (
+	apiKeyPrefix    = "sk_"
+	apiKeyShownPart = len(apiKeyPrefix) + 8
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// NewAPIKey generates a random key. Only its hash is meant to be stored, along with the
+// short prefix identifying it in listings.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:apiKeyShownPart], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the lookup hash of a key. Keys are random and long, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LooksLikeAPIKey reports whether s has the shape of a generated key, so that malformed
+// values can be rejected without a lookup.
+func LooksLikeAPIKey(s string) bool {
+	return strings.HasPrefix(s, apiKeyPrefix) && len(s) > apiKeyShownPart
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Subject is the sub claim, the id of the caller's user.
+	Subject string
+	UserID  uuid.UUID
+	// Roles of the user, from the token and the role assignments of the tenant.
+	// A user without roles holds DefaultRole.
+	Roles []string
+	// TenantID is the tenant the token was issued for, empty when the token does not name one.
+	TenantID string
+	// APIKeyID is set for callers authenticated with an API key. Such callers are not bound to
+	// a user and may only use the routes their Scopes allow.
+	APIKeyID *uuid.UUID
+	Scopes   []string
+	// ClientCert is the subject of the verified TLS client certificate of the connection,
+	// empty without mutual TLS.
+	ClientCert string
+}
+
+// CertPrincipal returns the caller identified only by its verified TLS client certificate.
+// It is not bound to a user, so roles without users:all give it access to no one's data.
+func CertPrincipal(cert *x509.Certificate, roles []string) *Principal {
+	subject := cert.Subject.String()
+	return &Principal{Subject: subject, Roles: roles, ClientCert: subject}
+}
+
+// Can reports whether the caller holds the permission, through its roles or, for API keys,
+// its scopes.
+func (p *Principal) Can(perm string) bool {
+	if p.APIKeyID != nil {
+		if perm == PermAllUsers {
+			return true
+		}
+		for _, s := range p.Scopes {
+			if s == perm {
+				return true
+			}
+		}
+		return false
+	}
+
+	roles := p.Roles
+	if len(roles) == 0 {
+		roles = []string{DefaultRole}
+	}
+	for _, role := range roles {
+		if RoleGrants(role, perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config selects how bearer tokens are verified: with a shared HS256 secret, or with the RS256/ES256
+// public keys of a JWKS document read from a file or URL.
+type Config struct {
+	Secret       string
+	JWKSURL      string
+	JWKSFile     string
+	JWKSCacheTTL time.Duration
+	Issuer       string
+	Audience     string
+	// AdminClaim is a boolean claim granting the admin role.
+	AdminClaim string
+	// RolesClaim lists the roles of the user, unknown roles are ignored.
+	RolesClaim string
+	// TenantClaim names the tenant the token is valid for.
+	TenantClaim string
+	Leeway      time.Duration
+}
+
+// Enabled reports whether a verification key is configured.
+func (c Config) Enabled() bool {
+	return c.Secret != "" || c.JWKSURL != "" || c.JWKSFile != ""
+}
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Verifier checks bearer tokens and turns their claims into a Principal.
+type Verifier struct {
+	cfg    Config
+	jwks   *JWKS
+	parser *jwt.Parser
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if !cfg.Enabled() {
+		return nil, errors.New("no JWT secret or JWKS configured")
+	}
+	if cfg.AdminClaim == "" {
+		cfg.AdminClaim = "admin"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = "tenant"
+	}
+
+	v := &Verifier{cfg: cfg}
+	methods := []string{"HS256"}
+	if cfg.Secret == "" {
+		methods = []string{"RS256", "ES256"}
+		v.jwks = NewJWKS(cfg.JWKSURL, cfg.JWKSFile, cfg.JWKSCacheTTL)
+	}
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify validates the token signature and registered claims and returns the caller.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sub claim is not a user id", ErrInvalidToken)
+	}
+
+	p := &Principal{Subject: sub, UserID: userID}
+	if admin, ok := claims[v.cfg.AdminClaim].(bool); ok && admin {
+		p.Roles = append(p.Roles, RoleAdmin)
+	}
+	if roles, ok := claims[v.cfg.RolesClaim].([]interface{}); ok {
+		for _, r := range roles {
+			if role, ok := r.(string); ok && ValidRole(role) {
+				p.Roles = append(p.Roles, role)
+			}
+		}
+	}
+	if tenantID, ok := claims[v.cfg.TenantClaim].(string); ok {
+		p.TenantID = tenantID
+	}
+	return p, nil
+}
+
+func (v *Verifier) key(t *jwt.Token) (interface{}, error) {
+	if v.jwks == nil {
+		return []byte(v.cfg.Secret), nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	return v.jwks.Key(kid)
+}
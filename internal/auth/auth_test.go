@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret-0123456789"
+
+func mint(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func claimsFor(sub string) jwt.MapClaims {
+	return jwt.MapClaims{"sub": sub, "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": b64(key.N.Bytes()), "e": b64(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": b64(key.X.FillBytes(make([]byte, size))), "y": b64(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func jwksDoc(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+	raw, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return raw
+}
+
+func TestVerify_HS256(t *testing.T) {
+	v, err := NewVerifier(Config{Secret: testSecret, Issuer: "issuer", Audience: "subscriptions"})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	valid := func() jwt.MapClaims {
+		c := claimsFor(userID.String())
+		c["iss"] = "issuer"
+		c["aud"] = "subscriptions"
+		return c
+	}
+
+	p, err := v.Verify(mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", valid()))
+	require.NoError(t, err)
+	assert.Equal(t, userID, p.UserID)
+	assert.Empty(t, p.Roles)
+	assert.False(t, p.Can(PermAllUsers))
+	assert.Empty(t, p.TenantID)
+
+	admin := valid()
+	admin["admin"] = true
+	admin["tenant"] = "acme"
+	p, err = v.Verify(mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", admin))
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAdmin}, p.Roles)
+	assert.Equal(t, "acme", p.TenantID)
+
+	withRoles := valid()
+	withRoles["roles"] = []string{RoleAuditor, "superuser"}
+	p, err = v.Verify(mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", withRoles))
+	require.NoError(t, err)
+	assert.Equal(t, []string{RoleAuditor}, p.Roles, "unknown roles are ignored")
+
+	expired := valid()
+	expired["exp"] = time.Now().Add(-time.Hour).Unix()
+	noExp := valid()
+	delete(noExp, "exp")
+	badSub := valid()
+	badSub["sub"] = "ivan"
+	otherIssuer := valid()
+	otherIssuer["iss"] = "someone-else"
+	otherAudience := valid()
+	otherAudience["aud"] = "billing"
+
+	for name, token := range map[string]string{
+		"wrong secret":   mint(t, jwt.SigningMethodHS256, []byte("another-secret-0123456789"), "", valid()),
+		"expired":        mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", expired),
+		"no exp":         mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", noExp),
+		"sub not a uuid": mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", badSub),
+		"other issuer":   mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", otherIssuer),
+		"other audience": mint(t, jwt.SigningMethodHS256, []byte(testSecret), "", otherAudience),
+		"alg none":       mint(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", valid()),
+		"malformed":      "not.a.token",
+	} {
+		_, err := v.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func TestVerify_RS256FromJWKSFile(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(file, jwksDoc(t, rsaJWK("rsa-1", &key.PublicKey)), 0o600))
+
+	v, err := NewVerifier(Config{JWKSFile: file})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	p, err := v.Verify(mint(t, jwt.SigningMethodRS256, key, "rsa-1", claimsFor(userID.String())))
+	require.NoError(t, err)
+	assert.Equal(t, userID, p.UserID)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = v.Verify(mint(t, jwt.SigningMethodRS256, other, "rsa-1", claimsFor(userID.String())))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// a shared secret must not be accepted in place of the public key
+	_, err = v.Verify(mint(t, jwt.SigningMethodHS256, []byte(testSecret), "rsa-1", claimsFor(userID.String())))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestVerify_ES256FromJWKSURL(t *testing.T) {
+	first, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	second, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var (
+		rotated atomic.Bool
+		fetches atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if rotated.Load() {
+			w.Write(jwksDoc(t, ecJWK("ec-2", &second.PublicKey)))
+			return
+		}
+		w.Write(jwksDoc(t, ecJWK("ec-1", &first.PublicKey)))
+	}))
+	defer srv.Close()
+
+	v, err := NewVerifier(Config{JWKSURL: srv.URL, JWKSCacheTTL: time.Hour})
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	for i := 0; i < 3; i++ {
+		_, err = v.Verify(mint(t, jwt.SigningMethodES256, first, "ec-1", claimsFor(userID.String())))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), fetches.Load(), "keys are cached")
+
+	// a token signed with an unknown key refetches the set, at most once per interval
+	rotated.Store(true)
+	v.jwks.fetched = time.Now().Add(-2 * jwksRefetchInterval)
+	_, err = v.Verify(mint(t, jwt.SigningMethodES256, second, "ec-2", claimsFor(userID.String())))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	_, err = v.Verify(mint(t, jwt.SigningMethodES256, first, "ec-unknown", claimsFor(userID.String())))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestParseJWKS_Invalid(t *testing.T) {
+	for name, raw := range map[string]string{
+		"not json":      "{",
+		"no keys":       `{"keys":[]}`,
+		"only enc keys": `{"keys":[{"kty":"RSA","kid":"a","use":"enc","n":"AQAB","e":"AQAB"}]}`,
+		"off curve":     `{"keys":[{"kty":"EC","kid":"a","crv":"P-256","x":"AQ","y":"AQ"}]}`,
+	} {
+		_, err := ParseJWKS([]byte(raw))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNewAPIKey(t *testing.T) {
+	key, prefix, hash, err := NewAPIKey()
+	require.NoError(t, err)
+	assert.True(t, LooksLikeAPIKey(key))
+	assert.True(t, len(key) > 40)
+	assert.Equal(t, key[:len(prefix)], prefix)
+	assert.Equal(t, HashAPIKey(key), hash)
+	assert.NotContains(t, hash, key)
+
+	other, _, _, err := NewAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	assert.False(t, LooksLikeAPIKey("sk_"))
+	assert.False(t, LooksLikeAPIKey("Bearer abc"))
+}
+
+func TestPrincipal_Can(t *testing.T) {
+	keyID := uuid.New()
+	key := &Principal{APIKeyID: &keyID, Scopes: []string{PermSubscriptionsRead}}
+	assert.True(t, key.Can(PermSubscriptionsRead))
+	assert.True(t, key.Can(PermAllUsers), "API keys are not bound to a user")
+	assert.False(t, key.Can(PermSubscriptionsWrite))
+	assert.False(t, key.Can(PermRolesManage))
+
+	member := &Principal{UserID: uuid.New()}
+	assert.True(t, member.Can(PermSubscriptionsWrite), "users without roles are members")
+	assert.False(t, member.Can(PermAllUsers))
+	assert.False(t, member.Can(PermHistoryRead))
+
+	finance := &Principal{UserID: uuid.New(), Roles: []string{RoleFinance}}
+	assert.True(t, finance.Can(PermAggregateRead))
+	assert.True(t, finance.Can(PermAllUsers))
+	assert.False(t, finance.Can(PermSubscriptionsWrite))
+
+	auditor := &Principal{UserID: uuid.New(), Roles: []string{RoleAuditor}}
+	assert.True(t, auditor.Can(PermHistoryRead))
+	assert.False(t, auditor.Can(PermBudgetsWrite))
+
+	both := &Principal{UserID: uuid.New(), Roles: []string{RoleFinance, RoleMember}}
+	assert.True(t, both.Can(PermSubscriptionsWrite))
+	assert.True(t, both.Can(PermAllUsers))
+
+	admin := &Principal{UserID: uuid.New(), Roles: []string{RoleAdmin}}
+	for _, perms := range Roles {
+		for _, perm := range perms {
+			assert.True(t, admin.Can(perm), perm)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// jwksRefetchInterval limits how often an unknown kid triggers a refetch, so that tokens with
+// made-up key ids cannot hammer the key server.
+const jwksRefetchInterval = time.Minute
+
+// JWKS is a cached set of public keys, read from a URL or a file and refreshed every TTL,
+// or earlier when a token is signed with a key it does not know yet (key rotation).
+type JWKS struct {
+	url    string
+	file   string
+	ttl    time.Duration
+	client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]crypto.PublicKey
+	fetched time.Time
+}
+
+func NewJWKS(url, file string, ttl time.Duration) *JWKS {
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	return &JWKS{url: url, file: file, ttl: ttl, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Key returns the public key with the given id. An empty kid matches the only key of the set.
+func (j *JWKS) Key(kid string) (crypto.PublicKey, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	stale := time.Since(j.fetched) > j.ttl
+	key, ok := j.lookup(kid)
+	if stale || (!ok && time.Since(j.fetched) > jwksRefetchInterval) {
+		if err := j.refresh(); err != nil {
+			if ok {
+				// keep serving the cached key while the key server is unavailable
+				return key, nil
+			}
+			return nil, err
+		}
+		key, ok = j.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (j *JWKS) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(j.keys) == 1 {
+		for _, key := range j.keys {
+			return key, true
+		}
+	}
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+func (j *JWKS) refresh() error {
+	raw, err := j.read()
+	if err != nil {
+		return fmt.Errorf("read JWKS: %w", err)
+	}
+	keys, err := ParseJWKS(raw)
+	if err != nil {
+		return err
+	}
+	j.keys = keys
+	j.fetched = time.Now()
+	return nil
+}
+
+func (j *JWKS) read() ([]byte, error) {
+	if j.file != "" {
+		return os.ReadFile(j.file)
+	}
+	resp, err := j.client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes the RSA and EC signing keys of a JWKS document by key id.
+// Keys of other types or meant for encryption are skipped.
+func ParseJWKS(raw []byte) (map[string]crypto.PublicKey, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = k.rsa()
+		case "EC":
+			key, err = k.ecdsa()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jwk) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on the curve")
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url number")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
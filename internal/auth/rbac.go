@@ -0,0 +1,56 @@
+package auth
+
+// Permissions required by the routes. API key scopes are permissions as well.
+const (
+	PermSubscriptionsRead  = "subscriptions:read"
+	PermSubscriptionsWrite = "subscriptions:write"
+	PermAggregateRead      = "aggregate:read"
+	PermHistoryRead        = "history:read"
+	PermBudgetsRead        = "budgets:read"
+	PermBudgetsWrite       = "budgets:write"
+	// PermAllUsers extends the other permissions to the data of every user of the tenant,
+	// without it a caller only sees its own.
+	PermAllUsers       = "users:all"
+	PermWebhooksManage = "webhooks:manage"
+	PermAPIKeysManage  = "api-keys:manage"
+	PermRolesManage    = "roles:manage"
+	// PermSettingsManage changes the log level and reloads the configuration at runtime.
+	PermSettingsManage = "settings:manage"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleFinance = "finance"
+	RoleMember  = "member"
+	RoleAuditor = "auditor"
+)
+
+// Roles maps every role to the permissions it grants.
+var Roles = map[string][]string{
+	RoleAdmin: {
+		PermSubscriptionsRead, PermSubscriptionsWrite, PermAggregateRead, PermHistoryRead,
+		PermBudgetsRead, PermBudgetsWrite, PermAllUsers,
+		PermWebhooksManage, PermAPIKeysManage, PermRolesManage, PermSettingsManage,
+	},
+	RoleFinance: {PermSubscriptionsRead, PermAggregateRead, PermBudgetsRead, PermAllUsers},
+	RoleMember:  {PermSubscriptionsRead, PermSubscriptionsWrite, PermAggregateRead, PermBudgetsRead, PermBudgetsWrite},
+	RoleAuditor: {PermSubscriptionsRead, PermAggregateRead, PermHistoryRead, PermBudgetsRead, PermAllUsers},
+}
+
+// DefaultRole is held by authenticated users without any other role.
+const DefaultRole = RoleMember
+
+func ValidRole(role string) bool {
+	_, ok := Roles[role]
+	return ok
+}
+
+// RoleGrants reports whether the role grants the permission.
+func RoleGrants(role, perm string) bool {
+	for _, p := range Roles[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
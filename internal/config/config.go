@@ -2,7 +2,7 @@ package config
 
 import (
 	"fmt"
-	"os"
+	"time"
 )
 
 type Config struct {
@@ -14,29 +14,221 @@ type Config struct {
 	PostgresPort string
 	PgSSLMode    string
 	LogLevel     string
-}
 
-//LoadConfig loads the config from the environment
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
 
-func LoadConfig() *Config {
-	cfg := &Config{
-		AppPort:      getEnv("APP_PORT", "8080"),
-		PostgresUser: getEnv("POSTGRES_USER", "postgres"),
-		PostgresPass: getEnv("POSTGRES_PASSWORD", "postgres"),
-		PostgresDB:   getEnv("POSTGRES_DB", "subscription_db"),
-		PostgresHost: getEnv("POSTGRES_HOST", "127.0.0.1"),
-		PostgresPort: getEnv("POSTGRES_PORT", "5432"),
-		PgSSLMode:    getEnv("POSTGRES_SSLMODE", "disable"),
-		LogLevel:     getEnv("LOG_LEVEL", "info"),
-	}
-	return cfg
+	SwaggerEnabled bool
+
+	LogFormat          string
+	LogOutput          string
+	LogFile            string
+	LogFileMaxSizeMB   int
+	LogFileMaxBackups  int
+	LogFileMaxAgeDays  int
+	LogFileCompress    bool
+	LogRequestSampling int
+	DBSlowQuery        time.Duration
+
+	WebhookPollInterval time.Duration
+	WebhookMaxAttempts  int
+	WebhookTimeout      time.Duration
+	WebhookBackoffBase  time.Duration
+	WebhookBackoffMax   time.Duration
+	ExpirySweepInterval time.Duration
+
+	BudgetEvaluationInterval time.Duration
+
+	ReminderInterval   time.Duration
+	ReminderDaysBefore int
+	Notifier           string
+	NotifyTimeout      time.Duration
+	NotifyWebhookURL   string
+	SMTPHost           string
+	SMTPPort           string
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+
+	TenantHeader   string
+	TenantRequired bool
+
+	JWTSecret       string
+	JWTJWKSURL      string
+	JWTJWKSFile     string
+	JWTJWKSCacheTTL time.Duration
+	JWTIssuer       string
+	JWTAudience     string
+	JWTAdminClaim   string
+	JWTRolesClaim   string
+	JWTTenantClaim  string
+	JWTLeeway       time.Duration
+
+	RateLimitEnabled            bool
+	RateLimitStore              string
+	RateLimitPerMinute          int
+	RateLimitBurst              int
+	RateLimitExpensivePerMinute int
+	RateLimitExpensiveBurst     int
+	RateLimitIPPerMinute        int
+	RateLimitIPBurst            int
+	TrustedProxies              string
+
+	CORSAllowedOrigins string
+	CORSMaxAge         time.Duration
+
+	IdempotencyTTL time.Duration
+
+	QueryTimeout          time.Duration
+	QueryAggregateTimeout time.Duration
+
+	MetricsEnabled         bool
+	MetricsRefreshInterval time.Duration
+
+	TracingExporter    string
+	TracingSampleRatio float64
+
+	HealthCheckTimeout   time.Duration
+	HealthPoolSaturation float64
+
+	ServerReadTimeout       time.Duration
+	ServerReadHeaderTimeout time.Duration
+	ServerWriteTimeout      time.Duration
+	ServerIdleTimeout       time.Duration
+	ServerMaxHeaderBytes    int
+	ShutdownDrainDelay      time.Duration
+	ShutdownTimeout         time.Duration
+
+	TLSCertFile        string
+	TLSKeyFile         string
+	TLSClientCAFile    string
+	TLSClientAuth      string
+	TLSMinVersion      string
+	TLSReloadInterval  time.Duration
+	TLSClientCertRoles string
 }
 
-func getEnv(key, fallback string) string {
-	if value, ok := os.LookupEnv(key); ok {
-		return value
+// Load reads the settings from, in increasing order of precedence, the defaults, the YAML or
+// TOML file named by the -config flag or CONFIG_FILE, the environment and the command line
+// flags, see parseArgs. The settings are validated, all problems are reported at once.
+func Load(args []string) (*Config, error) {
+	l, err := newLoader(args)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		AppPort:      l.str("APP_PORT", "8080"),
+		PostgresUser: l.str("POSTGRES_USER", "postgres"),
+		PostgresPass: l.str("POSTGRES_PASSWORD", "postgres"),
+		PostgresDB:   l.str("POSTGRES_DB", "subscription_db"),
+		PostgresHost: l.str("POSTGRES_HOST", "127.0.0.1"),
+		PostgresPort: l.str("POSTGRES_PORT", "5432"),
+		PgSSLMode:    l.str("POSTGRES_SSLMODE", "disable"),
+		LogLevel:     l.str("LOG_LEVEL", "info"),
+
+		DBMaxOpenConns:    l.int("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    l.int("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime: l.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: l.duration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		SwaggerEnabled: l.bool("SWAGGER_ENABLED", true),
+
+		LogFormat:          l.str("LOG_FORMAT", "console"),
+		LogOutput:          l.str("LOG_OUTPUT", "stderr"),
+		LogFile:            l.str("LOG_FILE", "logs/app.log"),
+		LogFileMaxSizeMB:   l.int("LOG_FILE_MAX_SIZE_MB", 100),
+		LogFileMaxBackups:  l.int("LOG_FILE_MAX_BACKUPS", 5),
+		LogFileMaxAgeDays:  l.int("LOG_FILE_MAX_AGE_DAYS", 30),
+		LogFileCompress:    l.bool("LOG_FILE_COMPRESS", true),
+		LogRequestSampling: l.int("LOG_REQUEST_SAMPLING", 1),
+		DBSlowQuery:        l.duration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+
+		WebhookPollInterval: l.duration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+		WebhookMaxAttempts:  l.int("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookTimeout:      l.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookBackoffBase:  l.duration("WEBHOOK_BACKOFF_BASE", 30*time.Second),
+		WebhookBackoffMax:   l.duration("WEBHOOK_BACKOFF_MAX", 6*time.Hour),
+		ExpirySweepInterval: l.duration("EXPIRY_SWEEP_INTERVAL", time.Hour),
+
+		BudgetEvaluationInterval: l.duration("BUDGET_EVALUATION_INTERVAL", time.Hour),
+
+		ReminderInterval:   l.duration("REMINDER_INTERVAL", time.Hour),
+		ReminderDaysBefore: l.int("REMINDER_DAYS_BEFORE", 3),
+		Notifier:           l.str("NOTIFIER", "log"),
+		NotifyTimeout:      l.duration("NOTIFY_TIMEOUT", 10*time.Second),
+		NotifyWebhookURL:   l.str("NOTIFY_WEBHOOK_URL", ""),
+		SMTPHost:           l.str("SMTP_HOST", ""),
+		SMTPPort:           l.str("SMTP_PORT", "587"),
+		SMTPUsername:       l.str("SMTP_USERNAME", ""),
+		SMTPPassword:       l.str("SMTP_PASSWORD", ""),
+		SMTPFrom:           l.str("SMTP_FROM", ""),
+
+		TenantHeader:   l.str("TENANT_HEADER", "X-Tenant-ID"),
+		TenantRequired: l.bool("TENANT_REQUIRED", false),
+
+		JWTSecret:       l.str("JWT_SECRET", ""),
+		JWTJWKSURL:      l.str("JWT_JWKS_URL", ""),
+		JWTJWKSFile:     l.str("JWT_JWKS_FILE", ""),
+		JWTJWKSCacheTTL: l.duration("JWT_JWKS_CACHE_TTL", time.Hour),
+		JWTIssuer:       l.str("JWT_ISSUER", ""),
+		JWTAudience:     l.str("JWT_AUDIENCE", ""),
+		JWTAdminClaim:   l.str("JWT_ADMIN_CLAIM", "admin"),
+		JWTRolesClaim:   l.str("JWT_ROLES_CLAIM", "roles"),
+		JWTTenantClaim:  l.str("JWT_TENANT_CLAIM", "tenant"),
+		JWTLeeway:       l.duration("JWT_LEEWAY", 30*time.Second),
+
+		RateLimitEnabled:            l.bool("RATE_LIMIT_ENABLED", true),
+		RateLimitStore:              l.str("RATE_LIMIT_STORE", "memory"),
+		RateLimitPerMinute:          l.int("RATE_LIMIT_PER_MINUTE", 600),
+		RateLimitBurst:              l.int("RATE_LIMIT_BURST", 100),
+		RateLimitExpensivePerMinute: l.int("RATE_LIMIT_EXPENSIVE_PER_MINUTE", 30),
+		RateLimitExpensiveBurst:     l.int("RATE_LIMIT_EXPENSIVE_BURST", 10),
+		RateLimitIPPerMinute:        l.int("RATE_LIMIT_IP_PER_MINUTE", 1200),
+		RateLimitIPBurst:            l.int("RATE_LIMIT_IP_BURST", 200),
+		TrustedProxies:              l.str("TRUSTED_PROXIES", ""),
+
+		CORSAllowedOrigins: l.str("CORS_ALLOWED_ORIGINS", ""),
+		CORSMaxAge:         l.duration("CORS_MAX_AGE", 10*time.Minute),
+
+		IdempotencyTTL: l.duration("IDEMPOTENCY_TTL", 24*time.Hour),
+
+		QueryTimeout:          l.duration("QUERY_TIMEOUT", 5*time.Second),
+		QueryAggregateTimeout: l.duration("QUERY_TIMEOUT_AGGREGATE", 30*time.Second),
+
+		MetricsEnabled:         l.bool("METRICS_ENABLED", true),
+		MetricsRefreshInterval: l.duration("METRICS_REFRESH_INTERVAL", time.Minute),
+
+		TracingExporter:    l.str("TRACING_EXPORTER", "none"),
+		TracingSampleRatio: l.float("TRACING_SAMPLE_RATIO", 1),
+
+		HealthCheckTimeout:   l.duration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		HealthPoolSaturation: l.float("HEALTH_POOL_SATURATION", 0.9),
+
+		ServerReadTimeout:       l.duration("SERVER_READ_TIMEOUT", 15*time.Second),
+		ServerReadHeaderTimeout: l.duration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+		ServerWriteTimeout:      l.duration("SERVER_WRITE_TIMEOUT", time.Minute),
+		ServerIdleTimeout:       l.duration("SERVER_IDLE_TIMEOUT", 2*time.Minute),
+		ServerMaxHeaderBytes:    l.int("SERVER_MAX_HEADER_BYTES", 1<<20),
+		ShutdownDrainDelay:      l.duration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+		ShutdownTimeout:         l.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		TLSCertFile:        l.str("TLS_CERT_FILE", ""),
+		TLSKeyFile:         l.str("TLS_KEY_FILE", ""),
+		TLSClientCAFile:    l.str("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:      l.str("TLS_CLIENT_AUTH", "require"),
+		TLSMinVersion:      l.str("TLS_MIN_VERSION", "1.2"),
+		TLSReloadInterval:  l.duration("TLS_RELOAD_INTERVAL", 10*time.Second),
+		TLSClientCertRoles: l.str("TLS_CLIENT_CERT_ROLES", ""),
+	}
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	return fallback
+	return cfg, nil
 }
 
 func (c *Config) DSN() string {
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "8080", cfg.AppPort)
+	assert.Equal(t, 25, cfg.DBMaxOpenConns)
+	assert.Equal(t, 30*time.Minute, cfg.DBConnMaxLifetime)
+	assert.True(t, cfg.SwaggerEnabled)
+	assert.Equal(t, 5*time.Second, cfg.QueryTimeout)
+	assert.Equal(t, 30*time.Second, cfg.QueryAggregateTimeout)
+}
+
+func TestLoad_Layers(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+app_port: 9000
+log_level: debug
+postgres:
+  host: db
+  port: 6432
+tls_min_version: 1.0
+trusted_proxies: [10.0.0.0/8, 192.168.0.0/16]
+`)
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("POSTGRES_HOST", "env-host")
+
+	cfg, err := Load([]string{"-config", file, "--postgres-host=flag-host", "--swagger-enabled", "false"})
+	require.NoError(t, err)
+	assert.Equal(t, "9000", cfg.AppPort, "из файла")
+	assert.Equal(t, "6432", cfg.PostgresPort, "вложенные ключи файла")
+	assert.Equal(t, "1.0", cfg.TLSMinVersion, "значение файла читается как написано")
+	assert.Equal(t, "10.0.0.0/8,192.168.0.0/16", cfg.TrustedProxies)
+	assert.Equal(t, "warn", cfg.LogLevel, "переменная окружения важнее файла")
+	assert.Equal(t, "flag-host", cfg.PostgresHost, "флаг важнее переменной окружения")
+	assert.False(t, cfg.SwaggerEnabled)
+}
+
+func TestLoad_TOMLAndSecretFiles(t *testing.T) {
+	secret := writeFile(t, "jwt_secret", "s3cr3t\n")
+	file := writeFile(t, "config.toml", `
+app_port = 9000
+db_max_open_conns = 50
+
+[postgres]
+password_file = "`+writeFile(t, "pg_password", "from-file")+`"
+`)
+	t.Setenv(ConfigFileEnv, file)
+	t.Setenv("JWT_SECRET_FILE", secret)
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "9000", cfg.AppPort)
+	assert.Equal(t, 50, cfg.DBMaxOpenConns)
+	assert.Equal(t, "from-file", cfg.PostgresPass)
+	assert.Equal(t, "s3cr3t", cfg.JWTSecret, "перевод строки в конце файла отбрасывается")
+
+	t.Setenv("JWT_SECRET", "direct")
+	cfg, err = Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "direct", cfg.JWTSecret, "значение важнее файла на том же уровне")
+}
+
+func TestLoad_Errors(t *testing.T) {
+	file := writeFile(t, "config.yaml", "app_prot: 9000\n")
+	t.Setenv("RATE_LIMIT_BURST", "lots")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "25")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("DB_MAX_IDLE_CONNS", "100")
+	t.Setenv("QUERY_TIMEOUT_AGGREGATE", "2m")
+	t.Setenv("POSTGRES_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := Load([]string{"-config", file})
+	require.Error(t, err)
+	msg := err.Error()
+	for _, want := range []string{
+		"unknown setting APP_PROT",
+		`RATE_LIMIT_BURST: "lots" is not an integer`,
+		`DB_CONN_MAX_LIFETIME: "25" is not a duration`,
+		"POSTGRES_PASSWORD_FILE",
+	} {
+		assert.Contains(t, msg, want)
+	}
+
+	// with the parse errors fixed, validation reports the rest
+	t.Setenv("RATE_LIMIT_BURST", "10")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1h")
+	os.Unsetenv("POSTGRES_PASSWORD_FILE")
+	_, err = Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `LOG_FORMAT: "xml" is not one of json, console`)
+	assert.Contains(t, err.Error(), "DB_MAX_IDLE_CONNS (100) must not exceed DB_MAX_OPEN_CONNS (25)")
+	assert.Contains(t, err.Error(), "QUERY_TIMEOUT_AGGREGATE (2m0s) must be shorter than SERVER_WRITE_TIMEOUT (1m0s)")
+
+	_, err = Load([]string{"--app-port"})
+	assert.Error(t, err)
+	_, err = Load([]string{"-config", writeFile(t, "config.json", "{}")})
+	assert.Error(t, err)
+}
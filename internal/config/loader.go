@@ -0,0 +1,292 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigFileEnv names the config file when no -config flag is given.
+const ConfigFileEnv = "CONFIG_FILE"
+
+// source is a layer of settings keyed by their environment variable name.
+type source struct {
+	name   string
+	values map[string]string
+	lookup func(key string) (string, bool)
+}
+
+func (s source) get(key string) (string, bool) {
+	if s.lookup != nil {
+		return s.lookup(key)
+	}
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// loader reads settings from its sources, the last one winning, and collects the errors.
+// Every setting may also be given as KEY_FILE, the path of a file holding the value, which
+// keeps secrets out of the environment and of the config file.
+type loader struct {
+	sources []source
+	known   map[string]bool
+	errs    []error
+}
+
+func (l *loader) raw(key string) (string, bool) {
+	l.known[key] = true
+	l.known[key+"_FILE"] = true
+	for i := len(l.sources) - 1; i >= 0; i-- {
+		s := l.sources[i]
+		if v, ok := s.get(key); ok {
+			return v, true
+		}
+		if path, ok := s.get(key + "_FILE"); ok {
+			b, err := os.ReadFile(path)
+			if err != nil {
+				l.errs = append(l.errs, fmt.Errorf("%s_FILE (%s): %w", key, s.name, err))
+				return "", false
+			}
+			return strings.TrimRight(string(b), "\r\n"), true
+		}
+	}
+	return "", false
+}
+
+func (l *loader) str(key, fallback string) string {
+	if v, ok := l.raw(key); ok {
+		return v
+	}
+	return fallback
+}
+
+func (l *loader) int(key string, fallback int) int {
+	v, ok := l.raw(key)
+	if !ok {
+		return fallback
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %q is not an integer", key, v))
+		return fallback
+	}
+	return i
+}
+
+func (l *loader) bool(key string, fallback bool) bool {
+	v, ok := l.raw(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %q is not a boolean", key, v))
+		return fallback
+	}
+	return b
+}
+
+// duration reads a Go duration such as "30s" or "6h".
+func (l *loader) duration(key string, fallback time.Duration) time.Duration {
+	v, ok := l.raw(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %q is not a duration, e.g. 30s or 6h", key, v))
+		return fallback
+	}
+	return d
+}
+
+func (l *loader) float(key string, fallback float64) float64 {
+	v, ok := l.raw(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: %q is not a number", key, v))
+		return fallback
+	}
+	return f
+}
+
+// unknown reports the settings of the config file and of the flags that were never read,
+// most likely misspelled.
+func (l *loader) unknown() []error {
+	var errs []error
+	for _, s := range l.sources {
+		if s.values == nil {
+			continue
+		}
+		keys := make([]string, 0, len(s.values))
+		for key := range s.values {
+			if !l.known[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			errs = append(errs, fmt.Errorf("unknown setting %s (%s)", key, s.name))
+		}
+	}
+	return errs
+}
+
+// parseArgs reads the command line: -config PATH and one flag per setting, named after its
+// environment variable in lower case with dashes, e.g. --app-port=8000 or --jwt-secret-file PATH.
+func parseArgs(args []string) (configFile string, values map[string]string, err error) {
+	values = make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			return "", nil, fmt.Errorf("unexpected argument %q", arg)
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("flag -%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "config" {
+			configFile = value
+			continue
+		}
+		values[settingKey(name)] = value
+	}
+	return configFile, values, nil
+}
+
+// readFile reads a YAML or TOML config file. Nested tables are flattened with underscores, so
+// `postgres: {host: db}` sets POSTGRES_HOST, and lists are joined with commas.
+func readFile(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		var doc yaml.Node
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(doc.Content) > 0 {
+			if err := flattenYAML("", doc.Content[0], values); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	case ".toml":
+		var doc map[string]any
+		if err := toml.Unmarshal(b, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		flattenTOML("", doc, values)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file type %q, expected .yaml, .yml or .toml", path, ext)
+	}
+	return values, nil
+}
+
+// flattenYAML keeps the scalars as written, so that e.g. a TLS version 1.0 is not read as 1.
+func flattenYAML(prefix string, n *yaml.Node, values map[string]string) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := flattenYAML(joinKey(prefix, n.Content[i].Value), n.Content[i+1], values); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(n.Content))
+		for _, item := range n.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: %s must be a list of values", item.Line, prefix)
+			}
+			items = append(items, item.Value)
+		}
+		values[prefix] = strings.Join(items, ",")
+	case yaml.ScalarNode:
+		values[prefix] = n.Value
+	case yaml.AliasNode:
+		return flattenYAML(prefix, n.Alias, values)
+	}
+	return nil
+}
+
+func flattenTOML(prefix string, v any, values map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, item := range v {
+			flattenTOML(joinKey(prefix, key), item, values)
+		}
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, tomlScalar(item))
+		}
+		values[prefix] = strings.Join(items, ",")
+	default:
+		values[prefix] = tomlScalar(v)
+	}
+}
+
+func tomlScalar(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func joinKey(prefix, key string) string {
+	key = settingKey(key)
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// settingKey turns a file key or a flag name into the environment variable name of the setting.
+func settingKey(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+// newLoader layers the config file, the environment and the flags, in that order of precedence.
+func newLoader(args []string) (*loader, error) {
+	configFile, flags, err := parseArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if configFile == "" {
+		configFile = os.Getenv(ConfigFileEnv)
+	}
+
+	l := &loader{known: map[string]bool{ConfigFileEnv: true}}
+	if configFile != "" {
+		values, err := readFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		l.sources = append(l.sources, source{name: "config file " + configFile, values: values})
+	}
+	l.sources = append(l.sources,
+		source{name: "environment", lookup: os.LookupEnv},
+		source{name: "flags", values: flags},
+	)
+	return l, nil
+}
+
+func (l *loader) err() error {
+	return errors.Join(append(l.errs, l.unknown()...)...)
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validate checks the settings which would otherwise fail late, or silently misbehave, and
+// returns every problem found.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("APP_PORT", c.AppPort)
+	v.port("POSTGRES_PORT", c.PostgresPort)
+	v.required("POSTGRES_HOST", c.PostgresHost)
+	v.required("POSTGRES_DB", c.PostgresDB)
+	v.required("POSTGRES_USER", c.PostgresUser)
+	v.oneOf("POSTGRES_SSLMODE", c.PgSSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	v.oneOf("LOG_LEVEL", strings.ToLower(c.LogLevel), "debug", "info", "warn", "warning", "error", "fatal", "panic")
+	v.oneOf("LOG_FORMAT", c.LogFormat, "json", "console")
+	v.oneOf("LOG_OUTPUT", c.LogOutput, "stderr", "stdout", "file")
+	if c.LogOutput == "file" {
+		v.required("LOG_FILE", c.LogFile)
+		v.positive("LOG_FILE_MAX_SIZE_MB", c.LogFileMaxSizeMB)
+	}
+	v.positive("LOG_REQUEST_SAMPLING", c.LogRequestSampling)
+	v.positiveDuration("DB_SLOW_QUERY_THRESHOLD", c.DBSlowQuery)
+
+	v.nonNegative("DB_MAX_OPEN_CONNS", c.DBMaxOpenConns)
+	v.nonNegative("DB_MAX_IDLE_CONNS", c.DBMaxIdleConns)
+	if c.DBMaxOpenConns > 0 && c.DBMaxIdleConns > c.DBMaxOpenConns {
+		v.fail("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DBMaxIdleConns, c.DBMaxOpenConns)
+	}
+	v.nonNegativeDuration("DB_CONN_MAX_LIFETIME", c.DBConnMaxLifetime)
+	v.nonNegativeDuration("DB_CONN_MAX_IDLE_TIME", c.DBConnMaxIdleTime)
+
+	v.positiveDuration("WEBHOOK_POLL_INTERVAL", c.WebhookPollInterval)
+	v.positive("WEBHOOK_MAX_ATTEMPTS", c.WebhookMaxAttempts)
+	v.positiveDuration("WEBHOOK_TIMEOUT", c.WebhookTimeout)
+	v.positiveDuration("WEBHOOK_BACKOFF_BASE", c.WebhookBackoffBase)
+	if c.WebhookBackoffMax < c.WebhookBackoffBase {
+		v.fail("WEBHOOK_BACKOFF_MAX (%s) must not be shorter than WEBHOOK_BACKOFF_BASE (%s)", c.WebhookBackoffMax, c.WebhookBackoffBase)
+	}
+	v.positiveDuration("EXPIRY_SWEEP_INTERVAL", c.ExpirySweepInterval)
+	v.positiveDuration("BUDGET_EVALUATION_INTERVAL", c.BudgetEvaluationInterval)
+	v.positiveDuration("REMINDER_INTERVAL", c.ReminderInterval)
+	v.nonNegative("REMINDER_DAYS_BEFORE", c.ReminderDaysBefore)
+
+	v.oneOf("NOTIFIER", strings.ToLower(c.Notifier), "", "log", "smtp", "webhook")
+	switch strings.ToLower(c.Notifier) {
+	case "smtp":
+		v.required("SMTP_HOST", c.SMTPHost)
+		v.required("SMTP_FROM", c.SMTPFrom)
+		v.port("SMTP_PORT", c.SMTPPort)
+	case "webhook":
+		v.url("NOTIFY_WEBHOOK_URL", c.NotifyWebhookURL)
+	}
+
+	v.required("TENANT_HEADER", c.TenantHeader)
+	if c.JWTJWKSURL != "" {
+		v.url("JWT_JWKS_URL", c.JWTJWKSURL)
+	}
+	v.nonNegativeDuration("JWT_LEEWAY", c.JWTLeeway)
+
+	// checked while disabled as well, rate limiting may be turned on by a reload
+	v.oneOf("RATE_LIMIT_STORE", c.RateLimitStore, "memory", "postgres")
+	v.positive("RATE_LIMIT_PER_MINUTE", c.RateLimitPerMinute)
+	v.positive("RATE_LIMIT_BURST", c.RateLimitBurst)
+	v.positive("RATE_LIMIT_EXPENSIVE_PER_MINUTE", c.RateLimitExpensivePerMinute)
+	v.positive("RATE_LIMIT_EXPENSIVE_BURST", c.RateLimitExpensiveBurst)
+	v.positive("RATE_LIMIT_IP_PER_MINUTE", c.RateLimitIPPerMinute)
+	v.positive("RATE_LIMIT_IP_BURST", c.RateLimitIPBurst)
+	for _, origin := range strings.Split(c.CORSAllowedOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" && origin != "*" {
+			v.url("CORS_ALLOWED_ORIGINS", origin)
+		}
+	}
+	v.nonNegativeDuration("CORS_MAX_AGE", c.CORSMaxAge)
+	v.positiveDuration("IDEMPOTENCY_TTL", c.IdempotencyTTL)
+
+	if c.MetricsEnabled {
+		v.positiveDuration("METRICS_REFRESH_INTERVAL", c.MetricsRefreshInterval)
+	}
+	v.oneOf("TRACING_EXPORTER", c.TracingExporter, "otlp", "stdout", "none")
+	v.ratio("TRACING_SAMPLE_RATIO", c.TracingSampleRatio)
+
+	v.positiveDuration("HEALTH_CHECK_TIMEOUT", c.HealthCheckTimeout)
+	v.ratio("HEALTH_POOL_SATURATION", c.HealthPoolSaturation)
+
+	v.nonNegativeDuration("SERVER_READ_TIMEOUT", c.ServerReadTimeout)
+	v.nonNegativeDuration("SERVER_READ_HEADER_TIMEOUT", c.ServerReadHeaderTimeout)
+	v.nonNegativeDuration("SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout)
+	v.nonNegativeDuration("SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout)
+	v.positive("SERVER_MAX_HEADER_BYTES", c.ServerMaxHeaderBytes)
+	v.nonNegativeDuration("SHUTDOWN_DRAIN_DELAY", c.ShutdownDrainDelay)
+	v.positiveDuration("SHUTDOWN_TIMEOUT", c.ShutdownTimeout)
+
+	v.nonNegativeDuration("QUERY_TIMEOUT", c.QueryTimeout)
+	v.nonNegativeDuration("QUERY_TIMEOUT_AGGREGATE", c.QueryAggregateTimeout)
+	// past the write timeout the 504 could not be sent anymore
+	if c.ServerWriteTimeout > 0 {
+		if c.QueryTimeout == 0 || c.QueryTimeout >= c.ServerWriteTimeout {
+			v.fail("QUERY_TIMEOUT (%s) must be shorter than SERVER_WRITE_TIMEOUT (%s)", c.QueryTimeout, c.ServerWriteTimeout)
+		}
+		if c.QueryAggregateTimeout == 0 || c.QueryAggregateTimeout >= c.ServerWriteTimeout {
+			v.fail("QUERY_TIMEOUT_AGGREGATE (%s) must be shorter than SERVER_WRITE_TIMEOUT (%s)", c.QueryAggregateTimeout, c.ServerWriteTimeout)
+		}
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		v.fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLSClientCAFile != "" && c.TLSCertFile == "" {
+		v.fail("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	v.oneOf("TLS_CLIENT_AUTH", c.TLSClientAuth, "none", "verify_if_given", "require")
+	v.oneOf("TLS_MIN_VERSION", c.TLSMinVersion, "1.0", "1.1", "1.2", "1.3")
+	v.nonNegativeDuration("TLS_RELOAD_INTERVAL", c.TLSReloadInterval)
+
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) fail(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.fail("%s is required", key)
+	}
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.fail("%s: %q is not one of %s", key, value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) port(key, value string) {
+	if p, err := strconv.Atoi(value); err != nil || p < 1 || p > 65535 {
+		v.fail("%s: %q is not a port number", key, value)
+	}
+}
+
+func (v *validator) url(key, value string) {
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.fail("%s: %q is not an http(s) URL", key, value)
+	}
+}
+
+func (v *validator) positive(key string, value int) {
+	if value <= 0 {
+		v.fail("%s must be positive, got %d", key, value)
+	}
+}
+
+func (v *validator) nonNegative(key string, value int) {
+	if value < 0 {
+		v.fail("%s must not be negative, got %d", key, value)
+	}
+}
+
+func (v *validator) positiveDuration(key string, value time.Duration) {
+	if value <= 0 {
+		v.fail("%s must be positive, got %s", key, value)
+	}
+}
+
+func (v *validator) nonNegativeDuration(key string, value time.Duration) {
+	if value < 0 {
+		v.fail("%s must not be negative, got %s", key, value)
+	}
+}
+
+func (v *validator) ratio(key string, value float64) {
+	if value < 0 || value > 1 {
+		v.fail("%s must be between 0 and 1, got %g", key, value)
+	}
+}
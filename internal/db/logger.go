@@ -0,0 +1,84 @@
+package db
+
+import (
+	"REST-service-sub/internal/logger"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"time"
+)
+
+// gormLogger routes the GORM logs through zerolog, with the request IDs of the context. Failed
+// queries are logged as errors and the queries slower than slow as warnings, with their SQL and
+// duration; every query is logged at the debug level.
+type gormLogger struct {
+	slow  time.Duration
+	level gormlogger.LogLevel
+}
+
+func newGormLogger(slow time.Duration) gormlogger.Interface {
+	return &gormLogger{slow: slow, level: gormlogger.Info}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logger.Ctx(ctx).Info().Msgf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logger.Ctx(ctx).Warn().Msgf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logger.Ctx(ctx).Error().Msgf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	log := logger.Ctx(ctx)
+
+	var event *zerolog.Event
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		event = log.Error().Err(err)
+	case l.slow > 0 && elapsed > l.slow && l.level >= gormlogger.Warn:
+		event = log.Warn().Dur("threshold", l.slow)
+	case l.level >= gormlogger.Info:
+		event = log.Debug()
+	default:
+		return
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	event.Str("sql", sql).Dur("duration", elapsed)
+	if rows >= 0 {
+		event.Int64("rows", rows)
+	}
+	switch {
+	case err != nil:
+		event.Msg("query failed")
+	case l.slow > 0 && elapsed > l.slow:
+		event.Msg("slow query")
+	default:
+		event.Msg("query")
+	}
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"testing"
+	"time"
+)
+
+func TestGormLogger(t *testing.T) {
+	var buf bytes.Buffer
+	global, level := log.Logger, zerolog.GlobalLevel()
+	log.Logger = zerolog.New(&buf)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	defer func() {
+		log.Logger = global
+		zerolog.SetGlobalLevel(level)
+	}()
+
+	l := newGormLogger(100 * time.Millisecond)
+	query := func() (string, int64) { return "SELECT 1", 1 }
+
+	l.Trace(context.Background(), time.Now(), query, nil)
+	assert.Empty(t, buf.String(), "ожидали, что быстрый запрос не логируется на уровне info")
+
+	l.Trace(context.Background(), time.Now().Add(-time.Second), query, nil)
+	assert.Contains(t, buf.String(), `"level":"warn"`)
+	assert.Contains(t, buf.String(), `"message":"slow query"`)
+	assert.Contains(t, buf.String(), `"sql":"SELECT 1"`)
+	buf.Reset()
+
+	l.Trace(context.Background(), time.Now(), query, errors.New("boom"))
+	assert.Contains(t, buf.String(), `"level":"error"`)
+	buf.Reset()
+
+	l.Trace(context.Background(), time.Now(), query, gorm.ErrRecordNotFound)
+	assert.Empty(t, buf.String(), "ожидали, что record not found не логируется")
+
+	l.LogMode(gormlogger.Silent).Trace(context.Background(), time.Now(), query, errors.New("boom"))
+	assert.Empty(t, buf.String())
+
+	zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	l.Trace(context.Background(), time.Now(), query, nil)
+	assert.Contains(t, buf.String(), `"level":"debug"`)
+}
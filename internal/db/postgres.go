@@ -2,32 +2,37 @@ package db
 
 import (
 	"REST-service-sub/internal/config"
+	"REST-service-sub/internal/tracing"
 	"fmt"
 	_ "github.com/lib/pq"
+	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-	"log"
 )
 
 func NewGormDB(cfg *config.Config) (*gorm.DB, error) {
 	dsn := cfg.DSN()
-	gormLogger := logger.Default.LogMode(logger.Warn)
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
+		Logger: newGormLogger(cfg.DBSlowQuery),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed open gorm: %w", err)
 	}
 
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to install tracing: %w", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed get sqlDB: %w", err)
 	}
 
-	sqlDB.SetConnMaxLifetime(25)
-	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
-	log.Println("gorm connection successful...")
+	log.Info().Msg("gorm connection successful...")
 	return db, nil
 }
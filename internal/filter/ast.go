@@ -0,0 +1,82 @@
+package filter
+
+import (
+	"strings"
+)
+
+// Node is an element of a parsed filter expression: either a Logical group or a Comparison.
+type Node interface {
+	String() string
+}
+
+type LogicalOp string
+
+const (
+	And LogicalOp = ";"
+	Or  LogicalOp = ","
+)
+
+// Logical joins two or more operands with AND (';') or OR (',').
+type Logical struct {
+	Op       LogicalOp
+	Operands []Node
+}
+
+func (l *Logical) String() string {
+	parts := make([]string, len(l.Operands))
+	for i, o := range l.Operands {
+		parts[i] = o.String()
+	}
+	return "(" + strings.Join(parts, string(l.Op)) + ")"
+}
+
+type Operator string
+
+const (
+	OpEqual          Operator = "=="
+	OpNotEqual       Operator = "!="
+	OpGreater        Operator = "=gt="
+	OpGreaterOrEqual Operator = "=ge="
+	OpLess           Operator = "=lt="
+	OpLessOrEqual    Operator = "=le="
+	OpIn             Operator = "=in="
+	OpNotIn          Operator = "=out="
+	OpIsNull         Operator = "=isnull="
+	OpLike           Operator = "=like="
+)
+
+// operators maps every accepted spelling, including the FIQL-style aliases, to its canonical form.
+var operators = map[string]Operator{
+	"==":       OpEqual,
+	"!=":       OpNotEqual,
+	"=gt=":     OpGreater,
+	">":        OpGreater,
+	"=ge=":     OpGreaterOrEqual,
+	">=":       OpGreaterOrEqual,
+	"=lt=":     OpLess,
+	"<":        OpLess,
+	"=le=":     OpLessOrEqual,
+	"<=":       OpLessOrEqual,
+	"=in=":     OpIn,
+	"=out=":    OpNotIn,
+	"=isnull=": OpIsNull,
+	"=like=":   OpLike,
+}
+
+func (o Operator) multiValue() bool {
+	return o == OpIn || o == OpNotIn
+}
+
+// Comparison is a single `field<op>value` constraint. Args holds raw (unconverted) values.
+type Comparison struct {
+	Field    string
+	Operator Operator
+	Args     []string
+}
+
+func (c *Comparison) String() string {
+	if c.Operator.multiValue() {
+		return c.Field + string(c.Operator) + "(" + strings.Join(c.Args, ",") + ")"
+	}
+	return c.Field + string(c.Operator) + strings.Join(c.Args, ",")
+}
@@ -0,0 +1,98 @@
+package filter
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testSchema = Schema{
+	"id":           {Column: "id", Type: UUID},
+	"service_name": {Column: "service_name", Type: String},
+	"price":        {Column: "price", Type: Int},
+	"start_date":   {Column: "start_date", Type: Date},
+	"end_date":     {Column: "end_date", Type: Date, Nullable: true},
+}
+
+func TestParse(t *testing.T) {
+	node, err := Parse("price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true")
+	require.NoError(t, err)
+
+	and, ok := node.(*Logical)
+	require.True(t, ok)
+	assert.Equal(t, And, and.Op)
+	require.Len(t, and.Operands, 3)
+	assert.Equal(t, &Comparison{Field: "price", Operator: OpGreater, Args: []string{"500"}}, and.Operands[0])
+	assert.Equal(t, &Comparison{Field: "service_name", Operator: OpIn, Args: []string{"Netflix", "Spotify"}}, and.Operands[1])
+	assert.Equal(t, &Comparison{Field: "end_date", Operator: OpIsNull, Args: []string{"true"}}, and.Operands[2])
+}
+
+func TestParse_Precedence(t *testing.T) {
+	node, err := Parse("price>100;price<500,service_name=='Yandex Plus'")
+	require.NoError(t, err)
+	assert.Equal(t, "((price=gt=100;price=lt=500),service_name==Yandex Plus)", node.String())
+
+	node, err = Parse("price>100;(price<500,service_name==Netflix)")
+	require.NoError(t, err)
+	assert.Equal(t, "(price=gt=100;(price=lt=500,service_name==Netflix))", node.String())
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"price",
+		"price=gt=",
+		"price=between=1",
+		"(price==1",
+		"price==1;",
+		"price==(1,2)",
+		"service_name=='Netflix",
+		"price==1)",
+	} {
+		_, err := Parse(input)
+		var syntaxErr *SyntaxError
+		assert.ErrorAs(t, err, &syntaxErr, input)
+	}
+}
+
+func TestSchema_ToSQL(t *testing.T) {
+	node, err := Parse("price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true")
+	require.NoError(t, err)
+
+	sql, args, err := testSchema.ToSQL(node)
+	require.NoError(t, err)
+	assert.Equal(t, "(price > ? AND service_name IN ? AND end_date IS NULL)", sql)
+	assert.Equal(t, []interface{}{int64(500), []interface{}{"Netflix", "Spotify"}}, args)
+
+	node, err = Parse("start_date=ge=07-2025,service_name=like=yandex*")
+	require.NoError(t, err)
+	sql, args, err = testSchema.ToSQL(node)
+	require.NoError(t, err)
+	assert.Equal(t, "(start_date >= ? OR LOWER(service_name) LIKE ?)", sql)
+	assert.Equal(t, []interface{}{time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), "yandex%"}, args)
+
+	id := uuid.New()
+	node, err = Parse("id==" + id.String())
+	require.NoError(t, err)
+	_, args, err = testSchema.ToSQL(node)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{id}, args)
+}
+
+func TestSchema_Validate(t *testing.T) {
+	for _, input := range []string{
+		"password==secret",
+		"price==abc",
+		"price=like=5*",
+		"start_date=isnull=true",
+		"end_date=isnull=maybe",
+		"id=gt=" + uuid.NewString(),
+		"start_date==yesterday",
+	} {
+		node, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Error(t, testSchema.Validate(node), input)
+	}
+}
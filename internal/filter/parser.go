@@ -0,0 +1,248 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	maxLength = 4096
+	maxDepth  = 16
+)
+
+// SyntaxError points at the position in the expression where parsing failed.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("filter syntax error at position %d: %s", e.Pos, e.Msg)
+}
+
+// Parse turns an RSQL/FIQL expression such as
+//
+//	price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true
+//
+// into an AST. ';' (AND) binds tighter than ',' (OR), parentheses group sub-expressions.
+// Values containing reserved characters or spaces can be quoted with "..." or '...'.
+func Parse(input string) (Node, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, &SyntaxError{Pos: 0, Msg: "empty expression"}
+	}
+	if len(input) > maxLength {
+		return nil, &SyntaxError{Pos: maxLength, Msg: "expression is too long"}
+	}
+	p := &parser{input: input}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.peek())
+	}
+	return node, nil
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	return p.input[p.pos]
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Pos: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	return p.parseLogical(depth, Or, p.parseAnd)
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	return p.parseLogical(depth, And, p.parseConstraint)
+}
+
+func (p *parser) parseLogical(depth int, op LogicalOp, operand func(int) (Node, error)) (Node, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+	operands := []Node{first}
+	for {
+		p.skipSpaces()
+		if p.eof() || p.peek() != op[0] {
+			break
+		}
+		p.pos++
+		next, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &Logical{Op: op, Operands: operands}, nil
+}
+
+func (p *parser) parseConstraint(depth int) (Node, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	if p.peek() != '(' {
+		return p.parseComparison()
+	}
+	if depth >= maxDepth {
+		return nil, p.errorf("expression is nested too deeply")
+	}
+	p.pos++
+	node, err := p.parseOr(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.eof() || p.peek() != ')' {
+		return nil, p.errorf("missing closing parenthesis")
+	}
+	p.pos++
+	return node, nil
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	start := p.pos
+	for !p.eof() && isSelectorChar(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected field name")
+	}
+	field := p.input[start:p.pos]
+
+	op, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if !p.eof() && p.peek() == '(' {
+		p.pos++
+		for {
+			arg, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			p.skipSpaces()
+			if p.eof() {
+				return nil, p.errorf("missing closing parenthesis")
+			}
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			if p.peek() != ',' {
+				return nil, p.errorf("unexpected %q in value list", p.peek())
+			}
+			p.pos++
+		}
+	} else {
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = []string{arg}
+	}
+
+	if !op.multiValue() && len(args) != 1 {
+		return nil, &SyntaxError{Pos: start, Msg: fmt.Sprintf("operator %s takes a single value", op)}
+	}
+	return &Comparison{Field: field, Operator: op, Args: args}, nil
+}
+
+func (p *parser) parseOperator() (Operator, error) {
+	rest := p.input[p.pos:]
+	for _, spelling := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(rest, spelling) {
+			p.pos += len(spelling)
+			return operators[spelling], nil
+		}
+	}
+	if strings.HasPrefix(rest, "=") {
+		end := strings.IndexByte(rest[1:], '=')
+		if end > 0 {
+			if op, ok := operators[rest[:end+2]]; ok {
+				p.pos += end + 2
+				return op, nil
+			}
+			return "", p.errorf("unknown operator %q", rest[:end+2])
+		}
+	}
+	return "", p.errorf("expected comparison operator")
+}
+
+func (p *parser) parseValue() (string, error) {
+	p.skipSpaces()
+	if p.eof() {
+		return "", p.errorf("expected value")
+	}
+	if q := p.peek(); q == '"' || q == '\'' {
+		return p.parseQuoted(q)
+	}
+	start := p.pos
+	for !p.eof() && !isReserved(p.peek()) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+func (p *parser) parseQuoted(quote byte) (string, error) {
+	start := p.pos
+	p.pos++
+	var b strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '\\' && p.pos+1 < len(p.input):
+			b.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", &SyntaxError{Pos: start, Msg: "unterminated quoted value"}
+}
+
+func isSelectorChar(c byte) bool {
+	return c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isReserved(c byte) bool {
+	switch c {
+	case '"', '\'', '(', ')', ';', ',', '=', '!', '<', '>', ' ', '\t':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type int
+
+const (
+	String Type = iota
+	Int
+	UUID
+	Date
+	Timestamp
+)
+
+// Field describes a filterable field: the column it maps to and how its values are converted.
+type Field struct {
+	Column   string
+	Type     Type
+	Nullable bool
+}
+
+// Schema is the whitelist of fields an expression may reference, keyed by their JSON name.
+type Schema map[string]Field
+
+// Validate checks that every comparison references a known field with a suitable operator and values.
+func (s Schema) Validate(n Node) error {
+	_, _, err := s.ToSQL(n)
+	return err
+}
+
+// ToSQL translates the expression into a parameterized condition usable with gorm's Where or Raw.
+func (s Schema) ToSQL(n Node) (string, []interface{}, error) {
+	switch n := n.(type) {
+	case *Logical:
+		joiner := " AND "
+		if n.Op == Or {
+			joiner = " OR "
+		}
+		parts := make([]string, 0, len(n.Operands))
+		var args []interface{}
+		for _, operand := range n.Operands {
+			sql, operandArgs, err := s.ToSQL(operand)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, sql)
+			args = append(args, operandArgs...)
+		}
+		return "(" + strings.Join(parts, joiner) + ")", args, nil
+	case *Comparison:
+		return s.comparisonSQL(n)
+	default:
+		return "", nil, fmt.Errorf("unsupported filter node %T", n)
+	}
+}
+
+func (s Schema) comparisonSQL(c *Comparison) (string, []interface{}, error) {
+	f, ok := s[c.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown filter field %q", c.Field)
+	}
+
+	switch c.Operator {
+	case OpIsNull:
+		if !f.Nullable {
+			return "", nil, fmt.Errorf("field %q is never null", c.Field)
+		}
+		isNull, err := strconv.ParseBool(c.Args[0])
+		if err != nil {
+			return "", nil, fmt.Errorf("=isnull= expects true or false, got %q", c.Args[0])
+		}
+		if isNull {
+			return f.Column + " IS NULL", nil, nil
+		}
+		return f.Column + " IS NOT NULL", nil, nil
+	case OpLike:
+		if f.Type != String {
+			return "", nil, fmt.Errorf("operator =like= is not supported for field %q", c.Field)
+		}
+		return "LOWER(" + f.Column + ") LIKE ?", []interface{}{likePattern(c.Args[0])}, nil
+	case OpGreater, OpGreaterOrEqual, OpLess, OpLessOrEqual:
+		if f.Type == UUID {
+			return "", nil, fmt.Errorf("operator %s is not supported for field %q", c.Operator, c.Field)
+		}
+	}
+
+	values := make([]interface{}, len(c.Args))
+	for i, raw := range c.Args {
+		v, err := f.convert(raw)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid value %q for field %q: %w", raw, c.Field, err)
+		}
+		values[i] = v
+	}
+
+	switch c.Operator {
+	case OpEqual:
+		return f.Column + " = ?", values, nil
+	case OpNotEqual:
+		return f.Column + " <> ?", values, nil
+	case OpGreater:
+		return f.Column + " > ?", values, nil
+	case OpGreaterOrEqual:
+		return f.Column + " >= ?", values, nil
+	case OpLess:
+		return f.Column + " < ?", values, nil
+	case OpLessOrEqual:
+		return f.Column + " <= ?", values, nil
+	case OpIn:
+		return f.Column + " IN ?", []interface{}{values}, nil
+	case OpNotIn:
+		return f.Column + " NOT IN ?", []interface{}{values}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported operator %s", c.Operator)
+}
+
+func (f Field) convert(raw string) (interface{}, error) {
+	switch f.Type {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case UUID:
+		return uuid.Parse(raw)
+	case Date:
+		return parseDate(raw)
+	case Timestamp:
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t, nil
+		}
+		return parseDate(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// parseDate accepts full dates as well as the MM-YYYY / YYYY-MM months used across the API.
+func parseDate(raw string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "01-2006", "2006-01"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, MM-YYYY or YYYY-MM")
+}
+
+// likePattern lowercases the value and turns RSQL '*' wildcards into SQL ones, escaping the rest.
+func likePattern(v string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(v))
+	return strings.ReplaceAll(escaped, "*", "%")
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/reload"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// SettingsReloader changes the runtime settings, see reload.Reloader.
+type SettingsReloader interface {
+	Reload() error
+	SetLogLevel(level string) error
+	LogLevel() string
+}
+
+type AdminHandler struct {
+	settings SettingsReloader
+	validate *validator.Validate
+}
+
+func NewAdminHandler(settings *reload.Reloader) *AdminHandler {
+	return &AdminHandler{
+		settings: settings,
+		validate: validator.New(),
+	}
+}
+
+// RegisterRoutes registers the admin routes. They require an authenticated caller even while
+// authentication is disabled for the rest of the API.
+func (h *AdminHandler) RegisterRoutes(r *gin.Engine) {
+	admin := r.Group("/admin", middleware.Authenticated(), middleware.Require(auth.PermSettingsManage))
+	admin.GET("/log-level", h.GetLogLevel)
+	admin.PUT("/log-level", h.SetLogLevel)
+	admin.POST("/reload", h.Reload)
+}
+
+// Get Log Level godoc
+// @Summary Get the log level
+// @Tags admin
+// @Produce json
+// @Success 200 {object} LogLevelDTO
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/log-level [get]
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, LogLevelDTO{Level: h.settings.LogLevel()})
+}
+
+// Set Log Level godoc
+// @Summary Set the log level
+// @Description Sets the log level until the next reload, the rest of the configuration is not re-read
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param payload body LogLevelDTO true "Log level"
+// @Success 200 {object} LogLevelDTO
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Router /admin/log-level [put]
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var dto LogLevelDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	// no reload here: a broken configuration must not block turning on debug logs
+	if err := h.settings.SetLogLevel(dto.Level); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, LogLevelDTO{Level: h.settings.LogLevel()})
+}
+
+// Reload Settings godoc
+// @Summary Reload the configuration
+// @Description Re-reads the log level, rate limits, feature toggles and CORS origins from the configuration, like SIGHUP. Other settings need a restart
+// @Tags admin
+// @Produce json
+// @Success 200 {object} LogLevelDTO
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/reload [post]
+func (h *AdminHandler) Reload(c *gin.Context) {
+	if err := h.settings.Reload(); err != nil {
+		respondWithError(c, http.StatusInternalServerError, "failed to reload configuration: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, LogLevelDTO{Level: h.settings.LogLevel()})
+}
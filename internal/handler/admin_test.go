@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"bytes"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeSettings struct {
+	level     string
+	reloads   int
+	reloadErr error
+}
+
+func (f *fakeSettings) Reload() error {
+	if f.reloadErr != nil {
+		return f.reloadErr
+	}
+	f.reloads++
+	f.level = "info"
+	return nil
+}
+
+func (f *fakeSettings) SetLogLevel(level string) error {
+	f.level = level
+	return nil
+}
+
+func (f *fakeSettings) LogLevel() string {
+	return f.level
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, err := auth.NewVerifier(auth.Config{Secret: testJWTSecret})
+	require.NoError(t, err)
+	settings := &fakeSettings{level: "info"}
+	router := gin.New()
+	router.Use(middleware.Authenticate(v, nil))
+	(&AdminHandler{settings: settings, validate: validator.New()}).RegisterRoutes(router)
+
+	put := func(token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", "/admin/log-level", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	admin := bearer(t, uuid.New(), true)
+
+	w := put(admin, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+	assert.Zero(t, settings.reloads, "смена уровня не перечитывает конфигурацию")
+
+	w = serveAs(router, "GET", "/admin/log-level", admin)
+	assert.JSONEq(t, `{"level":"debug"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, put(admin, `{"level":"verbose"}`).Code)
+	assert.Equal(t, http.StatusForbidden, put(bearer(t, uuid.New(), false), `{"level":"debug"}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, put("", `{"level":"debug"}`).Code)
+
+	w = serveAs(router, "POST", "/admin/reload", admin)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"level":"info"}`, w.Body.String())
+
+	settings.reloadErr = errors.New("LOG_FORMAT: \"xml\" is not one of json, console")
+	assert.Equal(t, http.StatusInternalServerError, serveAs(router, "POST", "/admin/reload", admin).Code)
+	w = put(admin, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code, "сломанная конфигурация не мешает сменить уровень")
+	assert.Equal(t, "debug", settings.level)
+}
+
+func TestAdmin_ClosedWithoutAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Authenticate(nil, nil))
+	(&AdminHandler{settings: &fakeSettings{level: "info"}, validate: validator.New()}).RegisterRoutes(router)
+
+	assert.Equal(t, http.StatusUnauthorized, serveAs(router, "GET", "/admin/log-level", "").Code)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type APIKeyHandler struct {
+	svc      service.APIKeyServiceInterface
+	validate *validator.Validate
+}
+
+func NewAPIKeyHandler(svc *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		svc:      svc,
+		validate: validator.New(),
+	}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(r *gin.Engine) {
+	// closed while authentication is disabled, anybody could issue themselves a key otherwise
+	g := r.Group("/api-keys", middleware.Authenticated(), middleware.Require(auth.PermAPIKeysManage))
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.DELETE("/:id", h.Revoke)
+}
+
+// scoped returns the service restricted to the tenant of the request.
+func (h *APIKeyHandler) scoped(c *gin.Context) service.APIKeyServiceInterface {
+	return h.svc.ForTenant(middleware.TenantID(c))
+}
+
+// Create API Key godoc
+// @Summary Issue API key
+// @Description Issue a key for non-interactive access, sent in the X-API-Key header. The key is returned only once, only its hash is stored
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param payload body CreateAPIKeyDTO true "Key name, scopes and optional expiry"
+// @Success 201 {object} APIKeyCreatedResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var dto CreateAPIKeyDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(time.Now()) {
+		respondWithError(c, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	k := &model.APIKey{
+		Name:      dto.Name,
+		Scopes:    dto.Scopes,
+		ExpiresAt: dto.ExpiresAt,
+	}
+	key, err := h.scoped(c).CreateKey(c.Request.Context(), k)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, APIKeyCreatedResponse{APIKey: *k, Key: key})
+}
+
+// List API Keys godoc
+// @Summary List API keys
+// @Tags api-keys
+// @Produce json
+// @Success 200 {array} model.APIKey
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api-keys [get]
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.scoped(c).ListKeys(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// Get API Key godoc
+// @Summary Get API key
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} model.APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api-keys/{id} [get]
+func (h *APIKeyHandler) Get(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	k, err := h.scoped(c).GetKey(c.Request.Context(), id)
+	if err != nil {
+		respondWithAPIKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, k)
+}
+
+// Revoke API Key godoc
+// @Summary Revoke API key
+// @Description Requests with a revoked key are rejected with 401, the key stays listed
+// @Tags api-keys
+// @Param id path string true "API key ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.scoped(c).RevokeKey(c.Request.Context(), id, time.Now()); err != nil {
+		respondWithAPIKeyError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func respondWithAPIKeyError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		respondWithError(c, http.StatusNotFound, err.Error())
+		return
+	}
+	respondWithError(c, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockAPIKeyService struct {
+	Created *model.APIKey
+	Tenant  string
+}
+
+func (m *mockAPIKeyService) CreateKey(ctx context.Context, k *model.APIKey) (string, error) {
+	k.ID = uuid.New()
+	k.Prefix = "sk_abcdefgh"
+	m.Created = k
+	return "sk_abcdefgh-secret", nil
+}
+
+func (m *mockAPIKeyService) ListKeys(ctx context.Context) ([]model.APIKey, error) {
+	return []model.APIKey{{ID: uuid.New(), Name: "billing export", Prefix: "sk_abcdefgh", Hash: "deadbeef"}}, nil
+}
+
+func (m *mockAPIKeyService) GetKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	return nil, service.ErrAPIKeyNotFound
+}
+
+func (m *mockAPIKeyService) RevokeKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return nil
+}
+
+func (m *mockAPIKeyService) ForTenant(tenantID string) service.APIKeyServiceInterface {
+	m.Tenant = tenantID
+	return m
+}
+
+func newTestAPIKeyRouter() (*gin.Engine, *mockAPIKeyService) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockAPIKeyService{}
+	h := &APIKeyHandler{svc: mockSvc, validate: validator.New()}
+	router := gin.New()
+	authenticateAsAdmin(router)
+	h.RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	router, mockSvc := newTestAPIKeyRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":       "billing export",
+		"scopes":     []string{auth.PermSubscriptionsRead, auth.PermAggregateRead},
+		"expires_at": time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	})
+	req, _ := http.NewRequest("POST", "/api-keys", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "sk_abcdefgh-secret", resp["key"])
+	assert.NotContains(t, resp, "hash")
+	if assert.NotNil(t, mockSvc.Created) {
+		assert.Equal(t, []string{auth.PermSubscriptionsRead, auth.PermAggregateRead}, []string(mockSvc.Created.Scopes))
+		assert.NotNil(t, mockSvc.Created.ExpiresAt)
+	}
+}
+
+func TestCreateAPIKey_Invalid(t *testing.T) {
+	router, _ := newTestAPIKeyRouter()
+
+	for _, payload := range []map[string]interface{}{
+		{"name": "no scopes"},
+		{"name": "unknown scope", "scopes": []string{"webhooks:write"}},
+		{"scopes": []string{auth.PermSubscriptionsRead}},
+		{"name": "expired", "scopes": []string{auth.PermSubscriptionsRead}, "expires_at": "2020-01-01T00:00:00Z"},
+	} {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("POST", "/api-keys", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400: %v", payload)
+	}
+}
+
+func TestListAPIKeys_HidesHash(t *testing.T) {
+	router, _ := newTestAPIKeyRouter()
+
+	req, _ := http.NewRequest("GET", "/api-keys", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "deadbeef")
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	router, _ := newTestAPIKeyRouter()
+
+	req, _ := http.NewRequest("DELETE", "/api-keys/"+uuid.NewString(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api-keys/"+uuid.NewString(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+type fakeAPIKeys map[string]*auth.Principal
+
+func (f fakeAPIKeys) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	if p, ok := f[key]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func TestAPIKey_ScopesPerRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyID := uuid.New()
+	keys := fakeAPIKeys{"sk_reader": {APIKeyID: &keyID, Scopes: []string{auth.PermSubscriptionsRead}}}
+
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	router.Use(middleware.Authenticate(nil, keys))
+	h.RegisterRoutes(router)
+	(&APIKeyHandler{svc: &mockAPIKeyService{}, validate: validator.New()}).RegisterRoutes(router)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, nil)
+		req.Header.Set(middleware.APIKeyHeader, "sk_reader")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/subscriptions")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, mockSvc.ListQuery.Filter, "user_id", "ключ не ограничен одним пользователем")
+
+	w = serve("DELETE", "/subscriptions/"+uuid.NewString())
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403 без scope subscriptions:write")
+	assert.Contains(t, w.Body.String(), auth.PermSubscriptionsWrite)
+
+	w = serve("GET", "/subscriptions/aggregate?from=01-2025&to=12-2025")
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403 без scope aggregate:read")
+
+	w = serve("GET", "/api-keys")
+	assert.Equal(t, http.StatusForbidden, w.Code, "ключ не может управлять ключами")
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// restrictedTo returns the user whose data the caller is limited to: the token subject,
+// or nil for callers with the users:all permission and when authentication is disabled.
+func restrictedTo(c *gin.Context) *uuid.UUID {
+	p := middleware.Principal(c)
+	if p == nil || p.Can(auth.PermAllUsers) {
+		return nil
+	}
+	return &p.UserID
+}
+
+// authorizeUser responds with 403 and returns false when the caller may not act on behalf of userID.
+func authorizeUser(c *gin.Context, userID uuid.UUID) bool {
+	if uid := restrictedTo(c); uid != nil && *uid != userID {
+		respondWithError(c, http.StatusForbidden, "access to the data of another user is denied")
+		return false
+	}
+	return true
+}
+
+// authorizeSubscription checks that the caller may access the subscription. Subscriptions of
+// other users are reported as not found, so that their ids cannot be probed.
+func authorizeSubscription(c *gin.Context, svc service.SubscriptionServiceInterface, id uuid.UUID) bool {
+	uid := restrictedTo(c)
+	if uid == nil {
+		return true
+	}
+	sub, err := svc.GetByID(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && sub.UserID != *uid):
+		respondWithError(c, http.StatusNotFound, "subscription not found")
+		return false
+	case err != nil:
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+	return true
+}
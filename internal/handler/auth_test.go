@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testJWTSecret = "test-secret-0123456789"
+
+func newAuthTestRouter(t *testing.T) (*gin.Engine, *mockService) {
+	gin.SetMode(gin.TestMode)
+	v, err := auth.NewVerifier(auth.Config{Secret: testJWTSecret})
+	require.NoError(t, err)
+
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	router.Use(middleware.Authenticate(v, nil))
+	h.RegisterRoutes(router)
+	(&WebhookHandler{svc: &mockWebhookService{}, validate: validator.New()}).RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func bearer(t *testing.T, userID uuid.UUID, admin bool) string {
+	t.Helper()
+	if admin {
+		return bearerWithRoles(t, userID, auth.RoleAdmin)
+	}
+	return bearerWithRoles(t, userID)
+}
+
+func bearerWithRoles(t *testing.T, userID uuid.UUID, roles ...string) string {
+	t.Helper()
+	claims := jwt.MapClaims{"sub": userID.String(), "exp": time.Now().Add(time.Hour).Unix()}
+	if len(roles) > 0 {
+		claims["roles"] = roles
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	require.NoError(t, err)
+	return "Bearer " + signed
+}
+
+// authenticateAsAdmin authenticates every request of the router as an admin, for the tests of
+// routes closed to anonymous callers.
+func authenticateAsAdmin(router *gin.Engine) {
+	const key = "sk_admin"
+	admin := &auth.Principal{UserID: uuid.New(), Roles: []string{auth.RoleAdmin}}
+	router.Use(func(c *gin.Context) { c.Request.Header.Set(middleware.APIKeyHeader, key) })
+	router.Use(middleware.Authenticate(nil, fakeAPIKeys{key: admin}))
+}
+
+func serveAs(router *gin.Engine, method, target, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuth_Unauthenticated(t *testing.T) {
+	router, _ := newAuthTestRouter(t)
+
+	w := serveAs(router, "GET", "/subscriptions", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "ожидали 401 без токена")
+}
+
+func TestAuth_ManagementClosedWithoutAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.Authenticate(nil, nil))
+	(&APIKeyHandler{svc: &mockAPIKeyService{}, validate: validator.New()}).RegisterRoutes(router)
+	(&RoleHandler{svc: &mockRoleService{}, validate: validator.New()}).RegisterRoutes(router)
+	(&WebhookHandler{svc: &mockWebhookService{}, validate: validator.New()}).RegisterRoutes(router)
+
+	for _, route := range [][2]string{
+		{"POST", "/api-keys"},
+		{"GET", "/api-keys"},
+		{"GET", "/roles"},
+		{"PUT", "/users/" + uuid.NewString() + "/roles"},
+		{"GET", "/webhooks"},
+	} {
+		w := serveAs(router, route[0], route[1], "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "ожидали 401 без аутентификации: %s %s", route[0], route[1])
+	}
+}
+
+func TestAuth_ListScopedToCaller(t *testing.T) {
+	router, mockSvc := newAuthTestRouter(t)
+	caller := uuid.New()
+
+	w := serveAs(router, "GET", "/subscriptions", bearer(t, caller, false))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, caller, mockSvc.ListQuery.Filter["user_id"])
+
+	w = serveAs(router, "GET", "/subscriptions?user_id="+uuid.NewString(), bearer(t, caller, false))
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403 для чужого user_id")
+
+	other := uuid.New()
+	w = serveAs(router, "GET", "/subscriptions?user_id="+other.String(), bearer(t, caller, true))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, other, mockSvc.ListQuery.Filter["user_id"])
+
+	mockSvc.ListQuery.Filter = nil
+	w = serveAs(router, "GET", "/subscriptions", bearer(t, caller, true))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, mockSvc.ListQuery.Filter, "user_id", "администратор видит подписки всех пользователей")
+}
+
+func TestAuth_GetOwnSubscriptionOnly(t *testing.T) {
+	router, mockSvc := newAuthTestRouter(t)
+	caller := uuid.New()
+	id := uuid.NewString()
+
+	mockSvc.Owner = caller
+	w := serveAs(router, "GET", "/subscriptions/"+id, bearer(t, caller, false))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockSvc.Owner = uuid.New()
+	for _, tc := range []struct{ method, target string }{
+		{"GET", "/subscriptions/" + id},
+		{"DELETE", "/subscriptions/" + id},
+		{"POST", "/subscriptions/" + id + "/pause"},
+	} {
+		w = serveAs(router, tc.method, tc.target, bearer(t, caller, false))
+		assert.Equal(t, http.StatusNotFound, w.Code, "ожидали 404 для чужой подписки: %s %s", tc.method, tc.target)
+	}
+
+	w = serveAs(router, "GET", "/subscriptions/"+id, bearer(t, caller, true))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuth_AggregateScopedToCaller(t *testing.T) {
+	router, mockSvc := newAuthTestRouter(t)
+	caller := uuid.New()
+
+	w := serveAs(router, "GET", "/subscriptions/aggregate?from=01-2025&to=12-2025", bearer(t, caller, false))
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, mockSvc.AggregateFilter.UserID) {
+		assert.Equal(t, caller, *mockSvc.AggregateFilter.UserID)
+	}
+
+	w = serveAs(router, "GET", "/subscriptions/aggregate?from=01-2025&to=12-2025&user_id="+uuid.NewString(), bearer(t, caller, false))
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403 для чужого user_id")
+
+	w = serveAs(router, "GET", "/subscriptions/aggregate?from=01-2025&to=12-2025", bearer(t, caller, true))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, mockSvc.AggregateFilter.UserID)
+}
+
+func TestAuth_SearchScopedToCaller(t *testing.T) {
+	router, mockSvc := newAuthTestRouter(t)
+	caller := uuid.New()
+
+	w := serveAs(router, "GET", "/subscriptions/search?q=netflix", bearer(t, caller, false))
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, mockSvc.SearchUser) {
+		assert.Equal(t, caller, *mockSvc.SearchUser)
+	}
+}
+
+func TestAuth_WebhooksAdminOnly(t *testing.T) {
+	router, _ := newAuthTestRouter(t)
+
+	w := serveAs(router, "GET", "/webhooks", bearer(t, uuid.New(), false))
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403 для обычного пользователя")
+
+	w = serveAs(router, "GET", "/webhooks", bearer(t, uuid.New(), true))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRBAC_Finance(t *testing.T) {
+	router, mockSvc := newAuthTestRouter(t)
+	token := bearerWithRoles(t, uuid.New(), auth.RoleFinance)
+
+	w := serveAs(router, "GET", "/subscriptions", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, mockSvc.ListQuery.Filter, "user_id", "finance читает подписки всех пользователей")
+
+	w = serveAs(router, "GET", "/subscriptions/aggregate?from=01-2025&to=12-2025", token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Nil(t, mockSvc.AggregateFilter.UserID)
+
+	w = serveAs(router, "DELETE", "/subscriptions/"+uuid.NewString(), token)
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403 для finance")
+	assert.JSONEq(t, `{"error":"missing permission subscriptions:write","missing_permission":"subscriptions:write"}`, w.Body.String())
+}
+
+func TestRBAC_History(t *testing.T) {
+	router, mockSvc := newAuthTestRouter(t)
+	owner := uuid.New()
+	mockSvc.Owner = owner
+	id := uuid.NewString()
+
+	w := serveAs(router, "GET", "/subscriptions/"+id+"/history", bearerWithRoles(t, uuid.New(), auth.RoleAuditor))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveAs(router, "GET", "/subscriptions/"+id+"/history", bearer(t, owner, false))
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403: у member нет history:read")
+	assert.Contains(t, w.Body.String(), auth.PermHistoryRead)
+
+	w = serveAs(router, "POST", "/subscriptions/"+id+"/cancel", bearerWithRoles(t, uuid.New(), auth.RoleAuditor))
+	assert.Equal(t, http.StatusForbidden, w.Code, "ожидали 403: auditor только читает")
+}
+
+func TestRBAC_HistoryOwnedByCurrentOwner(t *testing.T) {
+	// a role reading history without users:all, so the caller only sees its own subscriptions
+	const historian = "historian"
+	auth.Roles[historian] = []string{auth.PermHistoryRead}
+	t.Cleanup(func() { delete(auth.Roles, historian) })
+
+	router, mockSvc := newAuthTestRouter(t)
+	previous, owner := uuid.New(), uuid.New()
+	mockSvc.PreviousOwner, mockSvc.Owner = previous, owner
+	target := "/subscriptions/" + uuid.NewString() + "/history"
+
+	w := serveAs(router, "GET", target, bearerWithRoles(t, owner, historian))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = serveAs(router, "GET", target, bearerWithRoles(t, previous, historian))
+	assert.Equal(t, http.StatusNotFound, w.Code, "прежний владелец не должен видеть историю")
+}
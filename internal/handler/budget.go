@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type BudgetHandler struct {
+	svc      service.BudgetServiceInterface
+	validate *validator.Validate
+}
+
+func NewBudgetHandler(svc *service.BudgetService) *BudgetHandler {
+	return &BudgetHandler{
+		svc:      svc,
+		validate: validator.New(),
+	}
+}
+
+func (h *BudgetHandler) RegisterRoutes(r *gin.Engine) {
+	read := middleware.Require(auth.PermBudgetsRead)
+	write := middleware.Require(auth.PermBudgetsWrite)
+
+	r.PUT("/users/:user_id/budget", write, h.Set)
+	r.GET("/users/:user_id/budget", read, h.List)
+	r.DELETE("/users/:user_id/budget/:budget_id", write, h.Delete)
+	r.GET("/users/:user_id/budget/status", read, h.Status)
+	r.GET("/users/:user_id/alerts", read, h.Alerts)
+}
+
+// scoped returns the service restricted to the tenant of the request.
+func (h *BudgetHandler) scoped(c *gin.Context) service.BudgetServiceInterface {
+	return h.svc.ForTenant(middleware.TenantID(c))
+}
+
+// Set Budget godoc
+// @Summary Set monthly budget
+// @Description Create or replace a monthly budget of the user: overall, for a category or for a service
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param payload body SetBudgetDTO true "*category and service_name are optional and mutually exclusive*"
+// @Success 200 {object} model.Budget
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/budget [put]
+func (h *BudgetHandler) Set(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok || !authorizeUser(c, userID) {
+		return
+	}
+	var dto SetBudgetDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if dto.Category != nil && dto.ServiceName != nil {
+		respondWithError(c, http.StatusBadRequest, "category and service_name are mutually exclusive")
+		return
+	}
+
+	budget := &model.Budget{
+		UserID:       userID,
+		Category:     dto.Category,
+		ServiceName:  dto.ServiceName,
+		MonthlyLimit: *dto.MonthlyLimit,
+	}
+	if err := h.scoped(c).SetBudget(c.Request.Context(), budget); err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, budget)
+}
+
+// List Budgets godoc
+// @Summary List budgets
+// @Tags budgets
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {array} model.Budget
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/budget [get]
+func (h *BudgetHandler) List(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok || !authorizeUser(c, userID) {
+		return
+	}
+	budgets, err := h.scoped(c).ListBudgets(c.Request.Context(), userID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, budgets)
+}
+
+// Delete Budget godoc
+// @Summary Delete budget
+// @Tags budgets
+// @Param user_id path string true "User ID"
+// @Param budget_id path string true "Budget ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/budget/{budget_id} [delete]
+func (h *BudgetHandler) Delete(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok || !authorizeUser(c, userID) {
+		return
+	}
+	budgetID, ok := parseUUIDParam(c, "budget_id")
+	if !ok {
+		return
+	}
+	if err := h.scoped(c).DeleteBudget(c.Request.Context(), userID, budgetID); err != nil {
+		if errors.Is(err, service.ErrBudgetNotFound) {
+			respondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Budget Status godoc
+// @Summary Budget status
+// @Description Spent and remaining amounts of every budget in the current month, and the projected cost of the next month
+// @Tags budgets
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {array} service.BudgetStatus
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/budget/status [get]
+func (h *BudgetHandler) Status(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok || !authorizeUser(c, userID) {
+		return
+	}
+	statuses, err := h.scoped(c).Status(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
+// Budget Alerts godoc
+// @Summary List budget alerts
+// @Description Budget overspends, newest first
+// @Tags budgets
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10)"
+// @Success 200 {array} model.BudgetAlert
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/alerts [get]
+func (h *BudgetHandler) Alerts(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok || !authorizeUser(c, userID) {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	alerts, err := h.scoped(c).ListAlerts(c.Request.Context(), userID, limit, (page-1)*limit)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockBudgetService struct {
+	Set    *model.Budget
+	Tenant string
+}
+
+func (m *mockBudgetService) SetBudget(ctx context.Context, b *model.Budget) error {
+	b.ID = uuid.New()
+	m.Set = b
+	return nil
+}
+
+func (m *mockBudgetService) ListBudgets(ctx context.Context, userID uuid.UUID) ([]model.Budget, error) {
+	return []model.Budget{}, nil
+}
+
+func (m *mockBudgetService) DeleteBudget(ctx context.Context, userID, budgetID uuid.UUID) error {
+	return service.ErrBudgetNotFound
+}
+
+func (m *mockBudgetService) Status(ctx context.Context, userID uuid.UUID, now time.Time) ([]service.BudgetStatus, error) {
+	return []service.BudgetStatus{{
+		Budget:    model.Budget{UserID: userID, MonthlyLimit: 1000},
+		Period:    now.Format("2006-01"),
+		Spent:     1200,
+		Remaining: -200,
+		Projected: 900,
+		Exceeded:  true,
+	}}, nil
+}
+
+func (m *mockBudgetService) ListAlerts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]model.BudgetAlert, error) {
+	return []model.BudgetAlert{{ID: uuid.New(), UserID: userID, MonthlyLimit: 1000, Spent: 1200}}, nil
+}
+
+func (m *mockBudgetService) ForTenant(tenantID string) service.BudgetServiceInterface {
+	m.Tenant = tenantID
+	return m
+}
+
+func newTestBudgetRouter() (*gin.Engine, *mockBudgetService) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockBudgetService{}
+	h := &BudgetHandler{svc: mockSvc, validate: validator.New()}
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestSetBudget(t *testing.T) {
+	router, mockSvc := newTestBudgetRouter()
+
+	userID := uuid.New()
+	body, _ := json.Marshal(map[string]interface{}{"monthly_limit": 1500, "category": "video"})
+	req, _ := http.NewRequest("PUT", "/users/"+userID.String()+"/budget", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, userID, mockSvc.Set.UserID)
+	assert.Equal(t, 1500, mockSvc.Set.MonthlyLimit)
+	if assert.NotNil(t, mockSvc.Set.Category) {
+		assert.Equal(t, "video", *mockSvc.Set.Category)
+	}
+}
+
+func TestBudgetStatus(t *testing.T) {
+	router, _ := newTestBudgetRouter()
+
+	req, _ := http.NewRequest("GET", "/users/"+uuid.NewString()+"/budget/status", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var statuses []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &statuses))
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, float64(1200), statuses[0]["spent"])
+		assert.Equal(t, float64(-200), statuses[0]["remaining"])
+		assert.Equal(t, float64(900), statuses[0]["projected"])
+	}
+}
+
+func TestBudgetAlerts(t *testing.T) {
+	router, _ := newTestBudgetRouter()
+
+	req, _ := http.NewRequest("GET", "/users/"+uuid.NewString()+"/alerts", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestSetBudget_Invalid(t *testing.T) {
+	router, _ := newTestBudgetRouter()
+
+	for _, payload := range []map[string]interface{}{
+		{},
+		{"monthly_limit": -1},
+		{"monthly_limit": 100, "category": "video", "service_name": "Netflix"},
+	} {
+		body, _ := json.Marshal(payload)
+		req, _ := http.NewRequest("PUT", "/users/"+uuid.NewString()+"/budget", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, payload)
+	}
+}
+
+func TestDeleteBudget_NotFound(t *testing.T) {
+	router, _ := newTestBudgetRouter()
+
+	req, _ := http.NewRequest("DELETE", "/users/"+uuid.NewString()+"/budget/"+uuid.NewString(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
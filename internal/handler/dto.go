@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"REST-service-sub/internal/model"
 	"fmt"
 	"time"
 )
@@ -34,6 +35,7 @@ type SubscriptionResponse struct {
 	Price       int        `json:"price"`
 	StartDate   time.Time  `json:"start_date"`
 	EndDate     *time.Time `json:"end_date"`
+	Status      string     `json:"status" example:"active"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
@@ -51,6 +53,100 @@ type AggregatedResponse struct {
 	ToDate string `json:"to_date" example:"02-2023"`
 }
 
+// CreateWebhookDTO registers an endpoint for subscription events.
+//
+//swagger:model CreateWebhookDTO
+type CreateWebhookDTO struct {
+	//URL receiving POSTed events
+	//required: true
+	URL string `json:"url" validate:"required,url"`
+	//Event types to deliver, all of them when empty
+	EventTypes []string `json:"event_types" validate:"dive,oneof=subscription.created subscription.updated subscription.deleted subscription.expired subscription.paused subscription.resumed subscription.cancelled"`
+	//Signing secret, generated when omitted
+	Secret *string `json:"secret,omitempty" validate:"omitempty,min=16"`
+}
+
+// WebhookCreatedResponse is the registered endpoint. The secret is only ever returned here.
+//
+//swagger:model WebhookCreatedResponse
+type WebhookCreatedResponse struct {
+	model.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// SetBudgetDTO sets a monthly budget of a user, optionally limited to a category or a service.
+//
+//swagger:model SetBudgetDTO
+type SetBudgetDTO struct {
+	//Monthly limit in RUB
+	//required: true
+	MonthlyLimit *int `json:"monthly_limit" validate:"required,min=0"`
+	//Only subscriptions to services of this category *Optional*
+	Category *string `json:"category,omitempty" validate:"omitempty,min=1"`
+	//Only subscriptions to this service *Optional*
+	ServiceName *string `json:"service_name,omitempty" validate:"omitempty,min=1"`
+}
+
+// SetMembersDTO replaces the members sharing the cost of a subscription with its payer.
+//
+//swagger:model SetMembersDTO
+type SetMembersDTO struct {
+	//Members, an empty list makes the subscription personal again
+	Members []MemberDTO `json:"members" validate:"dive"`
+}
+
+// MemberDTO is a member paying either a weight-proportional part or a fixed monthly amount.
+//
+//swagger:model MemberDTO
+type MemberDTO struct {
+	//User UUID
+	//required: true
+	UserID string `json:"user_id" validate:"required,uuid"`
+	//Share weight, mutually exclusive with amount
+	Weight *int `json:"weight,omitempty" validate:"omitempty,min=1"`
+	//Fixed monthly amount in RUB, mutually exclusive with weight
+	Amount *int `json:"amount,omitempty" validate:"omitempty,min=0"`
+}
+
+// CreateAPIKeyDTO issues an API key.
+//
+//swagger:model CreateAPIKeyDTO
+type CreateAPIKeyDTO struct {
+	//Name telling what the key is used for
+	//required: true
+	Name string `json:"name" validate:"required,max=100"`
+	//Granted scopes
+	//required: true
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=subscriptions:read subscriptions:write aggregate:read history:read budgets:read budgets:write"`
+	//Expiry (RFC 3339), the key does not expire when omitted
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyCreatedResponse is the issued key. The key is only ever returned here.
+//
+//swagger:model APIKeyCreatedResponse
+type APIKeyCreatedResponse struct {
+	model.APIKey
+	Key string `json:"key"`
+}
+
+// SetRolesDTO replaces the roles assigned to a user.
+//
+//swagger:model SetRolesDTO
+type SetRolesDTO struct {
+	//Roles, an empty list leaves the user a member
+	Roles []string `json:"roles" validate:"dive,oneof=admin finance member auditor"`
+}
+
+// LogLevelDTO sets the log level at runtime.
+//
+//swagger:model LogLevelDTO
+type LogLevelDTO struct {
+	//Level: debug, info, warn, error, fatal or panic
+	//required: true
+	Level string `json:"level" validate:"required,oneof=debug info warn warning error fatal panic"`
+}
+
 func ParseMonthYear(s string) (time.Time, error) {
 	var t time.Time
 	var err error
@@ -1,13 +1,33 @@
 package handler
 
 import (
+	"context"
+	"net/http"
+
+	"REST-service-sub/internal/logger"
+	"REST-service-sub/internal/middleware"
+
 	"github.com/gin-gonic/gin"
 )
 
 type ErrorResponse struct {
-	Error string `json:"error" example:"error description"`
+	Error     string `json:"error" example:"error description"`
+	RequestID string `json:"request_id,omitempty" example:"3f2b8c1e-7a4d-4f0e-9b6a-2d5c8e1f0a7b"`
 }
 
+// respondWithError aborts with the error. A server error of a request that was cancelled by
+// its client or ran out of time is reported as such, with 499 or 504.
 func respondWithError(c *gin.Context, status int, message string) {
-	c.AbortWithStatusJSON(status, ErrorResponse{Error: message})
+	if status == http.StatusInternalServerError {
+		switch c.Request.Context().Err() {
+		case context.Canceled:
+			status, message = middleware.StatusClientClosedRequest, "client closed request"
+		case context.DeadlineExceeded:
+			status, message = http.StatusGatewayTimeout, "request timed out"
+		}
+	}
+	if status >= http.StatusInternalServerError {
+		logger.Ctx(c.Request.Context()).Error().Int("status", status).Str("error", message).Msg("request failed")
+	}
+	c.AbortWithStatusJSON(status, ErrorResponse{Error: message, RequestID: middleware.RequestID(c)})
 }
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	sseReplayBatch       = 500
+	sseHeartbeatInterval = 15 * time.Second
+)
+
+type EventsHandler struct {
+	events service.EventStreamInterface
+}
+
+func NewEventsHandler(events *service.EventBroker) *EventsHandler {
+	return &EventsHandler{events: events}
+}
+
+func (h *EventsHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/subscriptions/events", middleware.Require(auth.PermSubscriptionsRead), h.Stream)
+}
+
+// Stream Subscription Events godoc
+// @Summary Stream subscription changes (SSE)
+// @Description Server-Sent Events stream of subscription.created/updated/deleted/expired events. Send Last-Event-ID (or last_event_id) to resume after a disconnect
+// @Tags subscriptions
+// @Produce text/event-stream
+// @Param user_id query string false "Only events of this user"
+// @Param Last-Event-ID header string false "Id of the last received event"
+// @Param last_event_id query int false "Same as the Last-Event-ID header, for clients that cannot set headers"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/events [get]
+func (h *EventsHandler) Stream(c *gin.Context) {
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		uid, err := uuid.Parse(raw)
+		if err != nil {
+			respondWithError(c, http.StatusBadRequest, "invalid user_id format")
+			return
+		}
+		if !authorizeUser(c, uid) {
+			return
+		}
+		userID = &uid
+	}
+	if uid := restrictedTo(c); uid != nil {
+		userID = uid
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			respondWithError(c, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		lastID = id
+	}
+
+	tenantID := middleware.TenantID(c)
+
+	// subscribe before replaying so that nothing committed in between is lost
+	live, unsubscribe := h.events.Subscribe()
+	defer unsubscribe()
+
+	var backlog []model.SubscriptionEvent
+	if lastEventID != "" {
+		for {
+			batch, err := h.events.Since(c.Request.Context(), tenantID, lastID, userID, sseReplayBatch)
+			if err != nil {
+				respondWithError(c, http.StatusInternalServerError, err.Error())
+				return
+			}
+			backlog = append(backlog, batch...)
+			if len(batch) < sseReplayBatch {
+				break
+			}
+			lastID = batch[len(batch)-1].ID
+		}
+	}
+
+	// the stream outlives the server write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range backlog {
+		if err := writeSSE(c.Writer, event); err != nil {
+			return
+		}
+		lastID = event.ID
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-live:
+			if !ok {
+				// the client fell behind or the server is shutting down, it resumes from
+				// Last-Event-ID on reconnect
+				return
+			}
+			if event.ID <= lastID || event.TenantID != tenantID || (userID != nil && event.UserID != *userID) {
+				continue
+			}
+			if err := writeSSE(c.Writer, event); err != nil {
+				return
+			}
+			lastID = event.ID
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeSSE(w io.Writer, event model.SubscriptionEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockEventStream struct {
+	live    chan model.SubscriptionEvent
+	log     []model.SubscriptionEvent
+	sinceID int64
+}
+
+func (m *mockEventStream) Subscribe() (<-chan model.SubscriptionEvent, func()) {
+	return m.live, func() {}
+}
+
+func (m *mockEventStream) Since(ctx context.Context, tenantID string, lastID int64, userID *uuid.UUID, limit int) ([]model.SubscriptionEvent, error) {
+	m.sinceID = lastID
+	var out []model.SubscriptionEvent
+	for _, e := range m.log {
+		if e.ID > lastID && e.TenantID == tenantID && (userID == nil || e.UserID == *userID) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestStreamEvents_ResumeAndLive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := uuid.New()
+	other := uuid.New()
+	stream := &mockEventStream{
+		live: make(chan model.SubscriptionEvent, 5),
+		log: []model.SubscriptionEvent{
+			{TenantID: tenant.Default, ID: 1, Type: model.EventSubscriptionCreated, UserID: user, Payload: []byte(`{}`)},
+			{TenantID: tenant.Default, ID: 2, Type: model.EventSubscriptionCreated, UserID: other, Payload: []byte(`{}`)},
+			{TenantID: tenant.Default, ID: 3, Type: model.EventSubscriptionUpdated, UserID: user, Payload: []byte(`{}`)},
+		},
+	}
+	h := &EventsHandler{events: stream}
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	// already replayed, must not be sent twice
+	stream.live <- model.SubscriptionEvent{TenantID: tenant.Default, ID: 3, Type: model.EventSubscriptionUpdated, UserID: user, Payload: []byte(`{}`)}
+	stream.live <- model.SubscriptionEvent{TenantID: tenant.Default, ID: 4, Type: model.EventSubscriptionDeleted, UserID: other, Payload: []byte(`{}`)}
+	stream.live <- model.SubscriptionEvent{TenantID: "acme", ID: 5, Type: model.EventSubscriptionDeleted, UserID: user, Payload: []byte(`{}`)}
+	stream.live <- model.SubscriptionEvent{TenantID: tenant.Default, ID: 6, Type: model.EventSubscriptionDeleted, UserID: user, Payload: []byte(`{}`)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/subscriptions/events?user_id="+user.String(), nil)
+	req.Header.Set("Last-Event-ID", "1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, int64(1), stream.sinceID)
+
+	body := w.Body.String()
+	assert.Equal(t, 1, strings.Count(body, "id: 3\n"))
+	assert.Contains(t, body, "id: 3\nevent: subscription.updated\ndata: {")
+	assert.Contains(t, body, "id: 6\nevent: subscription.deleted\n")
+	assert.NotContains(t, body, "id: 1\n")
+	assert.NotContains(t, body, "id: 2\n")
+	assert.NotContains(t, body, "id: 4\n")
+	assert.NotContains(t, body, "id: 5\n")
+}
+
+func TestStreamEvents_InvalidLastEventID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &EventsHandler{events: &mockEventStream{live: make(chan model.SubscriptionEvent)}}
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseFields reads the sparse fieldset (fields=id,service_name,price). It responds with 400
+// and returns false when a field is not a subscription field.
+func parseFields(c *gin.Context) ([]string, bool) {
+	fields := splitList(c.Query("fields"))
+	if _, err := service.SubscriptionColumns(fields); err != nil {
+		respondWithError(c, http.StatusBadRequest, fmt.Sprintf("invalid fields: %s", err.Error()))
+		return nil, false
+	}
+	return fields, true
+}
+
+// parseExpand reads the relations to embed (expand=user,service).
+func parseExpand(c *gin.Context) ([]string, bool) {
+	relations := splitList(c.Query("expand"))
+	for _, rel := range relations {
+		if rel != service.ExpandUser && rel != service.ExpandService {
+			respondWithError(c, http.StatusBadRequest, fmt.Sprintf("invalid expand: unknown relation %q", rel))
+			return nil, false
+		}
+	}
+	return relations, true
+}
+
+func splitList(raw string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// selectedFields adds the columns needed to resolve the expanded relations to the requested fields.
+func selectedFields(fields, expand []string) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	selected := append([]string(nil), fields...)
+	for _, rel := range expand {
+		key := "user_id"
+		if rel == service.ExpandService {
+			key = "service_name"
+		}
+		if !contains(selected, key) {
+			selected = append(selected, key)
+		}
+	}
+	return selected
+}
+
+// renderSubscriptions keeps only the requested fields of every subscription (all of them when
+// fields is empty) and embeds the expanded relations that exist.
+func renderSubscriptions(subs []model.Subscription, fields, expand []string, exp *service.Expansions) ([]map[string]interface{}, error) {
+	out := make([]map[string]interface{}, 0, len(subs))
+	for _, sub := range subs {
+		raw, err := json.Marshal(sub)
+		if err != nil {
+			return nil, err
+		}
+		full := make(map[string]interface{})
+		if err := json.Unmarshal(raw, &full); err != nil {
+			return nil, err
+		}
+
+		item := full
+		if len(fields) > 0 {
+			item = make(map[string]interface{}, len(fields)+2)
+			for _, f := range fields {
+				item[f] = full[f]
+			}
+		}
+		for _, rel := range expand {
+			switch rel {
+			case service.ExpandUser:
+				if u, ok := exp.Users[sub.UserID]; ok {
+					item[rel] = u
+				}
+			case service.ExpandService:
+				if svc, ok := exp.Services[sub.ServiceName]; ok {
+					item[rel] = svc
+				}
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -5,8 +5,12 @@ import (
 	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/filter"
+	"REST-service-sub/internal/middleware"
 	"REST-service-sub/internal/model"
 	"REST-service-sub/internal/service"
 
@@ -28,23 +32,40 @@ func NewSubscriptionHandler(svc *service.SubscriptionService) *SubscriptionHandl
 }
 
 func (h *SubscriptionHandler) RegisterRoutes(r *gin.Engine) {
-	r.POST("/subscriptions", h.Create)
-	r.GET("/subscriptions/:id", h.Get)
-	r.PUT("/subscriptions/:id", h.Update)
-	r.DELETE("/subscriptions/:id", h.Delete)
-	r.GET("/subscriptions", h.List)
-	r.GET("/subscriptions/aggregate", h.Aggregate)
+	read := middleware.Require(auth.PermSubscriptionsRead)
+	write := middleware.Require(auth.PermSubscriptionsWrite)
+
+	r.POST("/subscriptions", write, h.Create)
+	r.GET("/subscriptions/:id", read, h.Get)
+	r.PUT("/subscriptions/:id", write, h.Update)
+	r.DELETE("/subscriptions/:id", write, h.Delete)
+	r.POST("/subscriptions/:id/pause", write, h.Pause)
+	r.POST("/subscriptions/:id/resume", write, h.Resume)
+	r.POST("/subscriptions/:id/cancel", write, h.Cancel)
+	r.GET("/subscriptions", read, h.List)
+	r.GET("/subscriptions/search", read, h.Search)
+	r.GET("/subscriptions/aggregate", middleware.Require(auth.PermAggregateRead), h.Aggregate)
+	r.GET("/subscriptions/:id/history", middleware.Require(auth.PermHistoryRead), h.History)
+}
+
+// scoped returns the service restricted to the tenant of the request.
+func (h *SubscriptionHandler) scoped(c *gin.Context) service.SubscriptionServiceInterface {
+	return h.svc.ForTenant(middleware.TenantID(c))
 }
 
 // Create Subscription godoc
 // @Summary Create subscription
-// @Description Create a new subscription
+// @Description Create a new subscription. A retry with the same Idempotency-Key and body returns the stored response
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Unique key of the request, makes retries safe"
 // @Param payload body CreateSubscriptionDTO true "*a field end_date is optional*"
 // @Success 201 {object} SubscriptionResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) Create(c *gin.Context) {
@@ -79,6 +100,9 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		respondWithError(c, http.StatusBadRequest, "invalid user_id")
 		return
 	}
+	if !authorizeUser(c, uid) {
+		return
+	}
 
 	sub := &model.Subscription{
 		ServiceName: dto.ServiceName,
@@ -88,7 +112,7 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 		EndDate:     endDate,
 	}
 
-	if err := h.svc.Create(sub); err != nil {
+	if err := h.scoped(c).Create(c.Request.Context(), sub); err != nil {
 		respondWithError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -114,7 +138,10 @@ func (h *SubscriptionHandler) Get(c *gin.Context) {
 		respondWithError(c, http.StatusBadRequest, "invalid id")
 		return
 	}
-	sub, err := h.svc.GetByID(id)
+	sub, err := h.scoped(c).GetByID(c.Request.Context(), id)
+	if uid := restrictedTo(c); err == nil && uid != nil && sub.UserID != *uid {
+		err = gorm.ErrRecordNotFound
+	}
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			respondWithError(c, http.StatusNotFound, "id not found")
@@ -136,6 +163,7 @@ func (h *SubscriptionHandler) Get(c *gin.Context) {
 // @Param payload body CreateSubscriptionDTO true "Updated subscription payload"
 // @Success 200 {object} SubscriptionResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(c *gin.Context) {
@@ -145,6 +173,9 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		respondWithError(c, http.StatusBadRequest, "invalid id")
 		return
 	}
+	if !authorizeSubscription(c, h.scoped(c), id) {
+		return
+	}
 	var dto CreateSubscriptionDTO
 	if err := c.ShouldBindJSON(&dto); err != nil {
 		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
@@ -172,6 +203,9 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		respondWithError(c, http.StatusBadRequest, "invalid user_id")
 		return
 	}
+	if !authorizeUser(c, uid) {
+		return
+	}
 
 	updated := &model.Subscription{
 		ServiceName: dto.ServiceName,
@@ -181,12 +215,12 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 		EndDate:     endDate,
 	}
 
-	if err := h.svc.Update(id, updated); err != nil {
+	if err := h.scoped(c).Update(c.Request.Context(), id, updated); err != nil {
 		if errors.Is(err, service.ErrSubscriptionNotFound) {
 			respondWithError(c, http.StatusNotFound, "subscription not found")
 			return
 		}
-		respondWithError(c, http.StatusNotFound, err.Error())
+		respondWithError(c, http.StatusInternalServerError, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, updated)
@@ -199,6 +233,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 // @Param id path string true "Subscription ID"
 // @Success 204
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
@@ -208,9 +243,12 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 		respondWithError(c, http.StatusBadRequest, "invalid id")
 		return
 	}
-	if err := h.svc.Delete(id); err != nil {
+	if !authorizeSubscription(c, h.scoped(c), id) {
+		return
+	}
+	if err := h.scoped(c).Delete(c.Request.Context(), id); err != nil {
 		if errors.Is(err, service.ErrSubscriptionNotFound) {
-			respondWithError(c, http.StatusInternalServerError, "subscription not found")
+			respondWithError(c, http.StatusNotFound, "subscription not found")
 			return
 		}
 		respondWithError(c, http.StatusInternalServerError, err.Error())
@@ -227,10 +265,15 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 // @Produce json
 // @Param user_id query string false "Filter by user UUID"
 // @Param service_name query string false "Filter by service name"
+// @Param status query string false "Filter by status: active, paused, cancelled, scheduled or ended"
+// @Param filter query string false "RSQL filter expression, e.g. price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true"
+// @Param fields query string false "Comma separated fields to return, e.g. id,service_name,price"
+// @Param expand query string false "Comma separated relations to embed: user, service"
 // @Param page query int false "Page number (default 1)"
 // @Param limit query int false "Items per page (default 10)"
 // @Success 200 {array} SubscriptionResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) List(c *gin.Context) {
@@ -242,14 +285,39 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 			respondWithError(c, http.StatusBadRequest, "invalid user_id format")
 			return
 		}
+		if !authorizeUser(c, uid) {
+			return
+		}
 		filter["user_id"] = uid
 
 	}
+	if uid := restrictedTo(c); uid != nil {
+		filter["user_id"] = *uid
+	}
 
 	if serviceName := c.Query("service_name"); serviceName != "" {
 		filter["service_name"] = serviceName
 	}
 
+	status := c.Query("status")
+	if status != "" && !contains(model.Statuses, status) {
+		respondWithError(c, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	expr, ok := parseFilterExpr(c)
+	if !ok {
+		return
+	}
+	fields, ok := parseFields(c)
+	if !ok {
+		return
+	}
+	expand, ok := parseExpand(c)
+	if !ok {
+		return
+	}
+
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || page < 1 {
 		page = 1
@@ -262,7 +330,64 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 
 	offset := (page - 1) * limit
 
-	subs, err := h.svc.List(filter, limit, offset)
+	subs, err := h.scoped(c).List(c.Request.Context(), service.ListQuery{
+		Filter: filter,
+		Expr:   expr,
+		Status: status,
+		Fields: selectedFields(fields, expand),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(fields) == 0 && len(expand) == 0 {
+		c.JSON(http.StatusOK, subs)
+		return
+	}
+
+	var exp *service.Expansions
+	if len(expand) > 0 {
+		exp, err = h.scoped(c).Expand(c.Request.Context(), subs, expand)
+		if err != nil {
+			respondWithError(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	items, err := renderSubscriptions(subs, fields, expand, exp)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// Search Subscriptions godoc
+// @Summary Search subscriptions by service name
+// @Description Typo tolerant search by service name ("netflx" finds "Netflix"), best matches first
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param q query string true "Service name or its beginning"
+// @Param limit query int false "Max items (default 10)"
+// @Success 200 {array} SubscriptionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/search [get]
+func (h *SubscriptionHandler) Search(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		respondWithError(c, http.StatusBadRequest, "query parameter q is required")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	subs, err := h.scoped(c).Search(c.Request.Context(), q, restrictedTo(c), limit)
 	if err != nil {
 		respondWithError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -280,8 +405,12 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 // @Param to query string true "End of period (MM-YYYY or YYYY-MM)"
 // @Param user_id query string false "Filter by user UUID"
 // @Param service_name query string false "Filter by service name"
+// @Param service_name_like query string false "Fuzzy filter by service name, same matching as /subscriptions/search"
+// @Param filter query string false "RSQL filter expression, same grammar as for /subscriptions"
+// @Param attribution query string false "payer (default): full price of the user's subscriptions, share: the user's share of shared subscriptions"
 // @Success 200 {object} models.AggregateResponse
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions/aggregate [get]
 func (h *SubscriptionHandler) Aggregate(c *gin.Context) {
@@ -305,14 +434,38 @@ func (h *SubscriptionHandler) Aggregate(c *gin.Context) {
 	if userID := c.Query("user_id"); userID != "" {
 		u, err := uuid.Parse(userID)
 		if err == nil {
+			if !authorizeUser(c, u) {
+				return
+			}
 			uid = &u
 		}
 	}
-	var svcName *string
+	if caller := restrictedTo(c); caller != nil {
+		uid = caller
+	}
+	attribution := c.DefaultQuery("attribution", service.AttributionPayer)
+	if attribution != service.AttributionPayer && attribution != service.AttributionShare {
+		respondWithError(c, http.StatusBadRequest, "invalid attribution")
+		return
+	}
+	params := service.AggregateFilter{
+		PeriodStart: pFrom,
+		PeriodEnd:   pTo,
+		UserID:      uid,
+		Attribution: attribution,
+	}
 	if sn := c.Query("service_name"); sn != "" {
-		svcName = &sn
+		params.ServiceName = &sn
 	}
-	total, err := h.svc.AggregateTotalCost(pFrom, pTo, uid, svcName)
+	if like := strings.TrimSpace(c.Query("service_name_like")); like != "" {
+		params.ServiceNameLike = &like
+	}
+	expr, ok := parseFilterExpr(c)
+	if !ok {
+		return
+	}
+	params.Expr = expr
+	total, err := h.scoped(c).AggregateTotalCost(c.Request.Context(), params)
 	if err != nil {
 		respondWithError(c, http.StatusInternalServerError, err.Error())
 		return
@@ -324,3 +477,55 @@ func (h *SubscriptionHandler) Aggregate(c *gin.Context) {
 		"to":         to,
 	})
 }
+
+// Subscription History godoc
+// @Summary Subscription history
+// @Description Change log of a subscription, oldest first, also available after the subscription is deleted
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} model.SubscriptionEvent
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/history [get]
+func (h *SubscriptionHandler) History(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	events, err := h.scoped(c).History(c.Request.Context(), id)
+	// the latest event names the current owner, also of a deleted subscription; without events
+	// the owner is unknown and the subscription is not shown to a restricted caller
+	if uid := restrictedTo(c); err == nil && uid != nil && (len(events) == 0 || events[len(events)-1].UserID != *uid) {
+		err = service.ErrSubscriptionNotFound
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrSubscriptionNotFound) {
+			respondWithError(c, http.StatusNotFound, "subscription not found")
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
+
+// parseFilterExpr parses the optional filter= query parameter and validates it against the
+// subscription field whitelist. It responds with 400 and returns false on a bad expression.
+func parseFilterExpr(c *gin.Context) (filter.Node, bool) {
+	raw := c.Query("filter")
+	if raw == "" {
+		return nil, true
+	}
+	expr, err := filter.Parse(raw)
+	if err == nil {
+		err = service.SubscriptionFilterSchema.Validate(expr)
+	}
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid filter: "+err.Error())
+		return nil, false
+	}
+	return expr, true
+}
@@ -1,24 +1,41 @@
 package handler
 
 import (
+	"REST-service-sub/internal/middleware"
 	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 )
 
 type mockService struct {
-	CreatedSub *model.Subscription
+	CreatedSub  *model.Subscription
+	SearchQuery string
+	SearchUser  *uuid.UUID
+	Owner       uuid.UUID
+	// PreviousOwner owned the subscription before Owner, History starts with its events
+	PreviousOwner   uuid.UUID
+	ListQuery       service.ListQuery
+	AggregateFilter service.AggregateFilter
+	TransitionErr   error
+	WriteErr        error
+	Tenant          string
+	// AggregateBlocks makes AggregateTotalCost wait for the request to be cancelled, like a slow query
+	AggregateBlocks bool
 }
 
-func (m *mockService) Create(sub *model.Subscription) error {
+func (m *mockService) Create(ctx context.Context, sub *model.Subscription) error {
 	sub.ID = uuid.New()
 	sub.CreatedAt = time.Now()
 	sub.UpdatedAt = time.Now()
@@ -26,25 +43,30 @@ func (m *mockService) Create(sub *model.Subscription) error {
 	return nil
 }
 
-func (m *mockService) GetByID(id uuid.UUID) (*model.Subscription, error) {
+func (m *mockService) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	owner := m.Owner
+	if owner == uuid.Nil {
+		owner = uuid.New()
+	}
 	return &model.Subscription{
 		ID:          id,
 		ServiceName: "Yandex Plus",
 		Price:       400,
-		UserID:      uuid.New(),
+		UserID:      owner,
 		StartDate:   time.Now(),
 	}, nil
 }
 
-func (m *mockService) Update(id uuid.UUID, sub *model.Subscription) error {
-	return nil
+func (m *mockService) Update(ctx context.Context, id uuid.UUID, sub *model.Subscription) error {
+	return m.WriteErr
 }
 
-func (m *mockService) Delete(id uuid.UUID) error {
-	return nil
+func (m *mockService) Delete(ctx context.Context, id uuid.UUID) error {
+	return m.WriteErr
 }
 
-func (m *mockService) List(filter map[string]interface{}, limit, offset int) ([]model.Subscription, error) {
+func (m *mockService) List(ctx context.Context, q service.ListQuery) ([]model.Subscription, error) {
+	m.ListQuery = q
 	return []model.Subscription{
 		{
 			ID:          uuid.New(),
@@ -56,16 +78,86 @@ func (m *mockService) List(filter map[string]interface{}, limit, offset int) ([]
 	}, nil
 }
 
-func (m *mockService) AggregateTotalCost(start, end time.Time, userID *uuid.UUID, serviceName *string) (int64, error) {
+func (m *mockService) Search(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]model.Subscription, error) {
+	m.SearchQuery = query
+	m.SearchUser = userID
+	return []model.Subscription{
+		{
+			ID:          uuid.New(),
+			ServiceName: "Netflix",
+			Price:       600,
+			UserID:      uuid.New(),
+			StartDate:   time.Now(),
+		},
+	}, nil
+}
+
+func (m *mockService) Expand(ctx context.Context, subs []model.Subscription, relations []string) (*service.Expansions, error) {
+	exp := &service.Expansions{Users: map[uuid.UUID]model.User{}, Services: map[string]model.Service{}}
+	category := "video"
+	for _, sub := range subs {
+		exp.Users[sub.UserID] = model.User{ID: sub.UserID, Name: "Ivan"}
+		exp.Services[sub.ServiceName] = model.Service{Name: sub.ServiceName, Category: &category}
+	}
+	return exp, nil
+}
+
+func (m *mockService) AggregateTotalCost(ctx context.Context, filter service.AggregateFilter) (int64, error) {
+	m.AggregateFilter = filter
+	if m.AggregateBlocks {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
 	return 800, nil
 }
 
+func (m *mockService) Pause(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	return m.transition(id, model.StatusPaused)
+}
+
+func (m *mockService) Resume(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	return m.transition(id, model.StatusActive)
+}
+
+func (m *mockService) Cancel(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	return m.transition(id, model.StatusCancelled)
+}
+
+func (m *mockService) transition(id uuid.UUID, status string) (*model.Subscription, error) {
+	if m.TransitionErr != nil {
+		return nil, m.TransitionErr
+	}
+	return &model.Subscription{ID: id, ServiceName: "Netflix", Price: 600, Status: status}, nil
+}
+
+func (m *mockService) History(ctx context.Context, id uuid.UUID) ([]model.SubscriptionEvent, error) {
+	first := m.Owner
+	if m.PreviousOwner != uuid.Nil {
+		first = m.PreviousOwner
+	}
+	return []model.SubscriptionEvent{
+		{ID: 1, Type: model.EventSubscriptionCreated, SubscriptionID: id, UserID: first},
+		{ID: 2, Type: model.EventSubscriptionUpdated, SubscriptionID: id, UserID: m.Owner},
+		{ID: 3, Type: model.EventSubscriptionCancelled, SubscriptionID: id, UserID: m.Owner},
+	}, nil
+}
+
+func (m *mockService) ForTenant(tenantID string) service.SubscriptionServiceInterface {
+	m.Tenant = tenantID
+	return m
+}
+
 func newTestHandler() *SubscriptionHandler {
+	h, _ := newTestHandlerWithMock()
+	return h
+}
+
+func newTestHandlerWithMock() (*SubscriptionHandler, *mockService) {
 	mockSvc := &mockService{}
 	return &SubscriptionHandler{
 		svc:      mockSvc,
 		validate: validator.New(),
-	}
+	}, mockSvc
 }
 
 // CRUD test
@@ -170,6 +262,47 @@ func TestDeleteSubscription(t *testing.T) {
 	assert.Equal(t, http.StatusNoContent, w.Code)
 }
 
+func TestUpdateDelete_ErrorStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	id := uuid.New()
+	body, _ := json.Marshal(map[string]interface{}{
+		"service_name": "Updated Name",
+		"price":        999,
+		"user_id":      uuid.New().String(),
+		"start_date":   "07-2025",
+	})
+	update := func(ctx context.Context) int {
+		req, _ := http.NewRequestWithContext(ctx, "PUT", "/subscriptions/"+id.String(), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	remove := func() int {
+		req, _ := http.NewRequest("DELETE", "/subscriptions/"+id.String(), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	mockSvc.WriteErr = service.ErrSubscriptionNotFound
+	assert.Equal(t, http.StatusNotFound, update(context.Background()))
+	assert.Equal(t, http.StatusNotFound, remove(), "ожидали 404 для отсутствующей подписки")
+
+	mockSvc.WriteErr = errors.New("connection reset")
+	assert.Equal(t, http.StatusInternalServerError, update(context.Background()), "ожидали 500 при ошибке БД")
+	assert.Equal(t, http.StatusInternalServerError, remove())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mockSvc.WriteErr = context.Canceled
+	assert.Equal(t, middleware.StatusClientClosedRequest, update(ctx), "ожидали 499 после отключения клиента")
+}
+
 func TestAggregateTotalCost(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	h := newTestHandler()
@@ -188,6 +321,114 @@ func TestAggregateTotalCost(t *testing.T) {
 	assert.Equal(t, float64(800), resp["total_cost"])
 }
 
+func TestSearchSubscriptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions/search?q=netflx", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "netflx", mockSvc.SearchQuery)
+	var subs []model.Subscription
+	err := json.Unmarshal(w.Body.Bytes(), &subs)
+	assert.NoError(t, err)
+	assert.Len(t, subs, 1)
+	assert.Equal(t, "Netflix", subs[0].ServiceName)
+}
+
+func TestAggregateTotalCost_CancelledOrTimedOut(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	mockSvc.AggregateBlocks = true
+	router := gin.New()
+	router.Use(middleware.Timeout(middleware.NewTimeouts(time.Minute).Route(20*time.Millisecond, "/subscriptions/aggregate")))
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions/aggregate?from=07-2025&to=08-2025", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code, "ожидали 504 по истечении таймаута")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, middleware.StatusClientClosedRequest, w.Code, "ожидали 499 после отключения клиента")
+
+	// CRUD routes keep the default timeout
+	req, _ = http.NewRequest("GET", "/subscriptions/"+uuid.New().String(), nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAggregateTotalCost_ServiceNameLike(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions/aggregate?from=07-2025&to=08-2025&service_name_like=yandex", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, mockSvc.AggregateFilter.ServiceNameLike) {
+		assert.Equal(t, "yandex", *mockSvc.AggregateFilter.ServiceNameLike)
+	}
+	assert.Nil(t, mockSvc.AggregateFilter.ServiceName)
+}
+
+func TestListSubscriptions_FilterExpression(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	q := url.Values{"filter": {"price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true"}}
+	req, _ := http.NewRequest("GET", "/subscriptions?"+q.Encode(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.NotNil(t, mockSvc.ListQuery.Expr) {
+		assert.Equal(t, "(price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true)", mockSvc.ListQuery.Expr.String())
+	}
+}
+
+func TestListSubscriptions_SparseFieldsAndExpand(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions?fields=id,service_name,price&expand=user", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"id", "service_name", "price", "user_id"}, mockSvc.ListQuery.Fields)
+
+	var items []map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &items)
+	assert.NoError(t, err)
+	if assert.Len(t, items, 1) {
+		assert.Len(t, items[0], 4)
+		assert.Equal(t, "Yandex Plus", items[0]["service_name"])
+		assert.Equal(t, float64(400), items[0]["price"])
+		assert.Contains(t, items[0], "id")
+		assert.NotContains(t, items[0], "user_id")
+		user, ok := items[0]["user"].(map[string]interface{})
+		if assert.True(t, ok) {
+			assert.Equal(t, "Ivan", user["name"])
+		}
+	}
+}
+
 // Negative tests
 func TestCreateSubscription_InvalidDateFormat(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -256,6 +497,26 @@ func TestCreateSubscription_InvalidUserIDFormat(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 из-за неверного UUID")
 }
 
+func TestErrorResponse_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler()
+	router := gin.New()
+	router.Use(middleware.Correlation())
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions/not-a-uuid", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "req-1", resp.RequestID, "ожидали request id в теле ошибки")
+	assert.Equal(t, "req-1", w.Header().Get(middleware.RequestIDHeader))
+}
+
 func TestUpdateSubscription_InvalidUUID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	h := newTestHandler()
@@ -323,3 +584,67 @@ func TestUpdateSubscription_InvalidDateFormat(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 из-за неверного формата даты")
 }
+
+func TestSearchSubscriptions_MissingQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions/search?q=", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 из-за пустого q")
+}
+
+func TestFilterExpression_Invalid(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	for _, target := range []string{
+		"/subscriptions?filter=" + url.QueryEscape("price=gt="),
+		"/subscriptions?filter=" + url.QueryEscape("password==secret"),
+		"/subscriptions/aggregate?from=07-2025&to=08-2025&filter=" + url.QueryEscape("price==abc"),
+	} {
+		req, _ := http.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestListSubscriptions_UnknownFieldOrRelation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	for _, target := range []string{"/subscriptions?fields=id,password", "/subscriptions?expand=payments"} {
+		req, _ := http.NewRequest("GET", target, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		assert.Contains(t, w.Body.String(), "unknown", target)
+	}
+}
+
+func TestListSubscriptions_TenantScoped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	router.Use(middleware.Tenant("X-Tenant-ID", false))
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme", mockSvc.Tenant)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"REST-service-sub/internal/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessChecker reports whether the service can serve requests, see health.Checker.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) health.Report
+}
+
+type HealthHandler struct {
+	checker ReadinessChecker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// RegisterRoutes registers the probes; they must be registered before authentication.
+func (h *HealthHandler) RegisterRoutes(r *gin.Engine) {
+	r.GET("/livez", h.Live)
+	r.GET("/readyz", h.Ready)
+	// kept for existing probes, same as /livez
+	r.GET("/health", h.Live)
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description The process is running; dependencies are not checked
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /livez [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Checks the database connection, the connection pool saturation and the schema migration version. Fails while the server shuts down
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"REST-service-sub/internal/health"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type fakeReadiness struct {
+	report health.Report
+}
+
+func (f *fakeReadiness) Ready(ctx context.Context) health.Report {
+	return f.report
+}
+
+func TestHealthProbes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := &fakeReadiness{report: health.Report{Status: health.StatusOK, Checks: map[string]health.CheckResult{
+		"database": {Status: health.StatusOK},
+	}}}
+	router := gin.New()
+	(&HealthHandler{checker: checker}).RegisterRoutes(router)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, serve("/livez").Code)
+	assert.Equal(t, http.StatusOK, serve("/readyz").Code)
+
+	checker.report = health.Report{Status: health.StatusFail, Checks: map[string]health.CheckResult{
+		"database": {Status: health.StatusFail, Error: "connection refused"},
+	}}
+	w := serve("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, "ожидали 503 при недоступной БД")
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, "connection refused", report.Checks["database"].Error)
+	assert.Equal(t, http.StatusOK, serve("/livez").Code, "ожидали, что liveness не зависит от БД")
+}
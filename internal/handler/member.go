@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type MemberHandler struct {
+	svc      service.MemberServiceInterface
+	subs     service.SubscriptionServiceInterface
+	validate *validator.Validate
+}
+
+func NewMemberHandler(svc *service.MemberService, subs *service.SubscriptionService) *MemberHandler {
+	return &MemberHandler{
+		svc:      svc,
+		subs:     subs,
+		validate: validator.New(),
+	}
+}
+
+func (h *MemberHandler) RegisterRoutes(r *gin.Engine) {
+	read := middleware.Require(auth.PermSubscriptionsRead)
+
+	r.PUT("/subscriptions/:id/members", middleware.Require(auth.PermSubscriptionsWrite), h.Set)
+	r.GET("/subscriptions/:id/members", read, h.List)
+	r.GET("/users/:user_id/shared", read, h.Shared)
+}
+
+// scoped returns the service restricted to the tenant of the request.
+func (h *MemberHandler) scoped(c *gin.Context) service.MemberServiceInterface {
+	return h.svc.ForTenant(middleware.TenantID(c))
+}
+
+// Set Members godoc
+// @Summary Set subscription members
+// @Description Replace the users sharing the cost of a subscription with its payer. Fixed amounts are taken first, the rest of the price is split by weight, the payer covers the remainder
+// @Tags members
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param payload body SetMembersDTO true "*every member has either weight or amount*"
+// @Success 200 {array} model.SubscriptionMember
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/members [put]
+func (h *MemberHandler) Set(c *gin.Context) {
+	subID, ok := parseUUIDParam(c, "id")
+	if !ok || !h.authorizePayer(c, subID) {
+		return
+	}
+	var dto SetMembersDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	members := make([]model.SubscriptionMember, len(dto.Members))
+	for i, m := range dto.Members {
+		if (m.Weight == nil) == (m.Amount == nil) {
+			respondWithError(c, http.StatusBadRequest, "every member needs either weight or amount")
+			return
+		}
+		members[i] = model.SubscriptionMember{
+			UserID: uuid.MustParse(m.UserID),
+			Weight: m.Weight,
+			Amount: m.Amount,
+		}
+	}
+
+	members, err := h.scoped(c).SetMembers(c.Request.Context(), subID, members)
+	if err != nil {
+		respondWithMemberError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// List Members godoc
+// @Summary List subscription members
+// @Tags members
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {array} model.SubscriptionMember
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/members [get]
+func (h *MemberHandler) List(c *gin.Context) {
+	subID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	members, err := h.scoped(c).ListMembers(c.Request.Context(), subID)
+	if err != nil {
+		respondWithMemberError(c, err)
+		return
+	}
+	if uid := restrictedTo(c); uid != nil && !isMember(members, *uid) && !h.authorizePayer(c, subID) {
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+// Shared Subscriptions godoc
+// @Summary List shared subscriptions of a user
+// @Description Subscriptions with members that the user pays for or is a member of, with the user's monthly share
+// @Tags members
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {array} service.SharedSubscription
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/shared [get]
+func (h *MemberHandler) Shared(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok || !authorizeUser(c, userID) {
+		return
+	}
+	shared, err := h.scoped(c).ListShared(c.Request.Context(), userID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, shared)
+}
+
+// authorizePayer checks that the caller pays for the subscription, see authorizeSubscription.
+func (h *MemberHandler) authorizePayer(c *gin.Context, subID uuid.UUID) bool {
+	if restrictedTo(c) == nil {
+		return true
+	}
+	return authorizeSubscription(c, h.subs.ForTenant(middleware.TenantID(c)), subID)
+}
+
+func isMember(members []model.SubscriptionMember, userID uuid.UUID) bool {
+	for _, m := range members {
+		if m.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func respondWithMemberError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrSubscriptionNotFound):
+		respondWithError(c, http.StatusNotFound, "subscription not found")
+	case errors.Is(err, service.ErrInvalidMembers):
+		respondWithError(c, http.StatusBadRequest, err.Error())
+	default:
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+	}
+}
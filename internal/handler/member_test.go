@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockMemberService struct {
+	Set    []model.SubscriptionMember
+	Tenant string
+}
+
+func (m *mockMemberService) SetMembers(ctx context.Context, subID uuid.UUID, members []model.SubscriptionMember) ([]model.SubscriptionMember, error) {
+	m.Set = members
+	return members, nil
+}
+
+func (m *mockMemberService) ListMembers(ctx context.Context, subID uuid.UUID) ([]model.SubscriptionMember, error) {
+	return nil, service.ErrSubscriptionNotFound
+}
+
+func (m *mockMemberService) ListShared(ctx context.Context, userID uuid.UUID) ([]service.SharedSubscription, error) {
+	return []service.SharedSubscription{{
+		Subscription: model.Subscription{ID: uuid.New(), ServiceName: "Spotify Family", Price: 500, UserID: uuid.New()},
+		Share:        100,
+	}}, nil
+}
+
+func (m *mockMemberService) ForTenant(tenantID string) service.MemberServiceInterface {
+	m.Tenant = tenantID
+	return m
+}
+
+func newTestMemberRouter() (*gin.Engine, *mockMemberService) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockMemberService{}
+	h := &MemberHandler{svc: mockSvc, subs: &mockService{}, validate: validator.New()}
+	router := gin.New()
+	h.RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestSetMembers(t *testing.T) {
+	router, mockSvc := newTestMemberRouter()
+
+	member := uuid.New()
+	body, _ := json.Marshal(map[string]interface{}{
+		"members": []map[string]interface{}{{"user_id": member.String(), "weight": 2}},
+	})
+	req, _ := http.NewRequest("PUT", "/subscriptions/"+uuid.NewString()+"/members", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	if assert.Len(t, mockSvc.Set, 1) {
+		assert.Equal(t, member, mockSvc.Set[0].UserID)
+		assert.Equal(t, 2, *mockSvc.Set[0].Weight)
+	}
+}
+
+func TestSetMembers_WeightOrAmount(t *testing.T) {
+	router, _ := newTestMemberRouter()
+
+	for _, m := range []map[string]interface{}{
+		{"user_id": uuid.NewString()},
+		{"user_id": uuid.NewString(), "weight": 1, "amount": 100},
+		{"user_id": uuid.NewString(), "weight": 0},
+		{"user_id": "not-a-uuid", "amount": 100},
+	} {
+		body, _ := json.Marshal(map[string]interface{}{"members": []map[string]interface{}{m}})
+		req, _ := http.NewRequest("PUT", "/subscriptions/"+uuid.NewString()+"/members", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 для участника %v", m)
+	}
+}
+
+func TestListMembers_NotFound(t *testing.T) {
+	router, _ := newTestMemberRouter()
+
+	req, _ := http.NewRequest("GET", "/subscriptions/"+uuid.NewString()+"/members", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSharedSubscriptions(t *testing.T) {
+	router, _ := newTestMemberRouter()
+
+	req, _ := http.NewRequest("GET", "/users/"+uuid.NewString()+"/shared", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp []service.SharedSubscription
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if assert.Len(t, resp, 1) {
+		assert.Equal(t, 100, resp[0].Share)
+	}
+}
+
+func TestAggregateTotalCost_Attribution(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	userID := uuid.NewString()
+	req, _ := http.NewRequest("GET", "/subscriptions/aggregate?from=07-2025&to=08-2025&attribution=share&user_id="+userID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, service.AttributionShare, mockSvc.AggregateFilter.Attribution)
+
+	req, _ = http.NewRequest("GET", "/subscriptions/aggregate?from=07-2025&to=08-2025&attribution=everyone", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 для неизвестной attribution")
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type RoleHandler struct {
+	svc      service.RoleServiceInterface
+	validate *validator.Validate
+}
+
+func NewRoleHandler(svc *service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		svc:      svc,
+		validate: validator.New(),
+	}
+}
+
+func (h *RoleHandler) RegisterRoutes(r *gin.Engine) {
+	authenticated := middleware.Authenticated()
+	manage := middleware.Require(auth.PermRolesManage)
+
+	r.GET("/roles", authenticated, h.Roles)
+	r.GET("/users/:user_id/roles", authenticated, manage, h.List)
+	r.PUT("/users/:user_id/roles", authenticated, manage, h.Set)
+}
+
+// scoped returns the service restricted to the tenant of the request.
+func (h *RoleHandler) scoped(c *gin.Context) service.RoleServiceInterface {
+	return h.svc.ForTenant(middleware.TenantID(c))
+}
+
+// List Roles godoc
+// @Summary List roles
+// @Description Every role with the permissions it grants
+// @Tags roles
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Router /roles [get]
+func (h *RoleHandler) Roles(c *gin.Context) {
+	c.JSON(http.StatusOK, auth.Roles)
+}
+
+// List User Roles godoc
+// @Summary List roles of a user
+// @Description Roles assigned in the tenant, the roles carried by the user's tokens are not included
+// @Tags roles
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {array} string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok {
+		return
+	}
+	roles, err := h.scoped(c).ListRoles(c.Request.Context(), userID)
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// Set User Roles godoc
+// @Summary Set roles of a user
+// @Description Replace the roles assigned to the user in the tenant. Users without roles are members
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Param payload body SetRolesDTO true "Roles: admin, finance, member or auditor"
+// @Success 200 {array} string
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{user_id}/roles [put]
+func (h *RoleHandler) Set(c *gin.Context) {
+	userID, ok := parseUUIDParam(c, "user_id")
+	if !ok {
+		return
+	}
+	var dto SetRolesDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	roles, err := h.scoped(c).SetRoles(c.Request.Context(), userID, dto.Roles)
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownRole) {
+			respondWithError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockRoleService struct {
+	Roles  map[uuid.UUID][]string
+	Tenant string
+}
+
+func (m *mockRoleService) ListRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return append([]string{}, m.Roles[userID]...), nil
+}
+
+func (m *mockRoleService) SetRoles(ctx context.Context, userID uuid.UUID, roles []string) ([]string, error) {
+	for _, role := range roles {
+		if !auth.ValidRole(role) {
+			return nil, fmt.Errorf("%w %q", service.ErrUnknownRole, role)
+		}
+	}
+	m.Roles[userID] = roles
+	return roles, nil
+}
+
+func (m *mockRoleService) ForTenant(tenantID string) service.RoleServiceInterface {
+	m.Tenant = tenantID
+	return m
+}
+
+func newTestRoleRouter() (*gin.Engine, *mockRoleService) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockRoleService{Roles: map[uuid.UUID][]string{}}
+	h := &RoleHandler{svc: mockSvc, validate: validator.New()}
+	router := gin.New()
+	authenticateAsAdmin(router)
+	h.RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestSetRoles(t *testing.T) {
+	router, mockSvc := newTestRoleRouter()
+	userID := uuid.New()
+
+	body, _ := json.Marshal(map[string]interface{}{"roles": []string{auth.RoleFinance, auth.RoleAuditor}})
+	req, _ := http.NewRequest("PUT", "/users/"+userID.String()+"/roles", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{auth.RoleFinance, auth.RoleAuditor}, mockSvc.Roles[userID])
+
+	req, _ = http.NewRequest("GET", "/users/"+userID.String()+"/roles", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `["finance","auditor"]`, w.Body.String())
+}
+
+func TestSetRoles_Invalid(t *testing.T) {
+	router, _ := newTestRoleRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"roles": []string{"superuser"}})
+	req, _ := http.NewRequest("PUT", "/users/"+uuid.NewString()+"/roles", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 для неизвестной роли")
+}
+
+func TestListRolePermissions(t *testing.T) {
+	router, _ := newTestRoleRouter()
+
+	req, _ := http.NewRequest("GET", "/roles", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var roles map[string][]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &roles))
+	assert.Contains(t, roles[auth.RoleAuditor], auth.PermHistoryRead)
+	assert.NotContains(t, roles[auth.RoleMember], auth.PermAllUsers)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Pause Subscription godoc
+// @Summary Pause subscription
+// @Description Stop charging an active subscription; months fully covered by the pause are excluded from aggregation
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	h.transition(c, service.SubscriptionServiceInterface.Pause)
+}
+
+// Resume Subscription godoc
+// @Summary Resume subscription
+// @Description Resume a paused subscription, the current month is charged
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	h.transition(c, service.SubscriptionServiceInterface.Resume)
+}
+
+// Cancel Subscription godoc
+// @Summary Cancel subscription
+// @Description Cancel an active or paused subscription, the current month is the last one charged
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} SubscriptionResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	h.transition(c, service.SubscriptionServiceInterface.Cancel)
+}
+
+func (h *SubscriptionHandler) transition(c *gin.Context, action func(service.SubscriptionServiceInterface, context.Context, uuid.UUID, time.Time) (*model.Subscription, error)) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok || !authorizeSubscription(c, h.scoped(c), id) {
+		return
+	}
+	sub, err := action(h.scoped(c), c.Request.Context(), id, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSubscriptionNotFound):
+			respondWithError(c, http.StatusNotFound, "subscription not found")
+		case errors.Is(err, service.ErrInvalidTransition):
+			respondWithError(c, http.StatusConflict, err.Error())
+		default:
+			respondWithError(c, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSubscriptionTransitions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := newTestHandler()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	id := uuid.New()
+	for action, status := range map[string]string{
+		"pause":  model.StatusPaused,
+		"resume": model.StatusActive,
+		"cancel": model.StatusCancelled,
+	} {
+		req, _ := http.NewRequest("POST", "/subscriptions/"+id.String()+"/"+action, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code, action)
+		var resp model.Subscription
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, id, resp.ID)
+		assert.Equal(t, status, resp.Status, action)
+	}
+}
+
+func TestSubscriptionTransitions_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		id   string
+		err  error
+		want int
+	}{
+		{"invalid id", "not-a-uuid", nil, http.StatusBadRequest},
+		{"not found", uuid.NewString(), service.ErrSubscriptionNotFound, http.StatusNotFound},
+		{"invalid transition", uuid.NewString(), fmt.Errorf("%w: subscription is cancelled", service.ErrInvalidTransition), http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mockSvc := newTestHandlerWithMock()
+			mockSvc.TransitionErr = tt.err
+			router := gin.New()
+			h.RegisterRoutes(router)
+
+			req, _ := http.NewRequest("POST", "/subscriptions/"+tt.id+"/pause", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want, w.Code, "ожидали другой статус ответа")
+		})
+	}
+}
+
+func TestListSubscriptions_Status(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, mockSvc := newTestHandlerWithMock()
+	router := gin.New()
+	h.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/subscriptions?status=scheduled", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, model.StatusScheduled, mockSvc.ListQuery.Status)
+
+	req, _ = http.NewRequest("GET", "/subscriptions?status=deleted", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "ожидали 400 для неизвестного статуса")
+}
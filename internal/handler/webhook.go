@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/middleware"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	svc      service.WebhookServiceInterface
+	validate *validator.Validate
+}
+
+func NewWebhookHandler(svc *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		svc:      svc,
+		validate: validator.New(),
+	}
+}
+
+func (h *WebhookHandler) RegisterRoutes(r *gin.Engine) {
+	// endpoints receive the events of every user of the tenant
+	g := r.Group("/webhooks", middleware.Authenticated(), middleware.Require(auth.PermWebhooksManage))
+	g.POST("", h.Create)
+	g.GET("", h.List)
+	g.GET("/:id", h.Get)
+	g.DELETE("/:id", h.Delete)
+	g.GET("/:id/deliveries", h.ListDeliveries)
+	g.GET("/:id/deliveries/:delivery_id", h.GetDelivery)
+	g.POST("/:id/deliveries/:delivery_id/redeliver", h.Redeliver)
+}
+
+// scoped returns the service restricted to the tenant of the request.
+func (h *WebhookHandler) scoped(c *gin.Context) service.WebhookServiceInterface {
+	return h.svc.ForTenant(middleware.TenantID(c))
+}
+
+// Create Webhook godoc
+// @Summary Register webhook endpoint
+// @Description Register an endpoint for subscription lifecycle events. Deliveries are signed with HMAC-SHA256 (X-Webhook-Signature over "<X-Webhook-Timestamp>.<body>"); the secret is returned only once
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param payload body CreateWebhookDTO true "Endpoint URL, event type filter and optional secret"
+// @Success 201 {object} WebhookCreatedResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var dto CreateWebhookDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if err := h.validate.Struct(dto); err != nil {
+		respondWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	endpoint := &model.WebhookEndpoint{
+		URL:        dto.URL,
+		EventTypes: dto.EventTypes,
+	}
+	if dto.Secret != nil {
+		endpoint.Secret = *dto.Secret
+	}
+	if err := h.scoped(c).CreateEndpoint(c.Request.Context(), endpoint); err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, WebhookCreatedResponse{WebhookEndpoint: *endpoint, Secret: endpoint.Secret})
+}
+
+// List Webhooks godoc
+// @Summary List webhook endpoints
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} model.WebhookEndpoint
+// @Failure 500 {object} map[string]string
+// @Router /webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	endpoints, err := h.scoped(c).ListEndpoints(c.Request.Context())
+	if err != nil {
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, endpoints)
+}
+
+// Get Webhook godoc
+// @Summary Get webhook endpoint
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} model.WebhookEndpoint
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	endpoint, err := h.scoped(c).GetEndpoint(c.Request.Context(), id)
+	if err != nil {
+		respondWithWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, endpoint)
+}
+
+// Delete Webhook godoc
+// @Summary Delete webhook endpoint
+// @Description Delete an endpoint together with its deliveries
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.scoped(c).DeleteEndpoint(c.Request.Context(), id); err != nil {
+		respondWithWebhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// List Webhook Deliveries godoc
+// @Summary List webhook deliveries
+// @Description Deliveries of an endpoint, newest first
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Items per page (default 10)"
+// @Success 200 {array} model.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	deliveries, err := h.scoped(c).ListDeliveries(c.Request.Context(), id, limit, (page-1)*limit)
+	if err != nil {
+		respondWithWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Get Webhook Delivery godoc
+// @Summary Get webhook delivery
+// @Description A delivery with the history of its attempts
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} model.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseUUIDParam(c, "delivery_id")
+	if !ok {
+		return
+	}
+	delivery, err := h.scoped(c).GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondWithWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// Redeliver Webhook Delivery godoc
+// @Summary Redeliver webhook delivery
+// @Description Queue a delivery again with a fresh retry budget, including dead ones. A delivery being sent right now is rejected with 409
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 202 {object} model.WebhookDelivery
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseUUIDParam(c, "delivery_id")
+	if !ok {
+		return
+	}
+	delivery, err := h.scoped(c).Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		respondWithWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, "invalid "+name)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func respondWithWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrDeliveryNotFound):
+		respondWithError(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrDeliveryInFlight):
+		respondWithError(c, http.StatusConflict, err.Error())
+	default:
+		respondWithError(c, http.StatusInternalServerError, err.Error())
+	}
+}
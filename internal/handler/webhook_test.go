@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/service"
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type mockWebhookService struct {
+	Created      *model.WebhookEndpoint
+	Redelivered  uuid.UUID
+	RedeliverErr error
+	Tenant       string
+}
+
+func (m *mockWebhookService) CreateEndpoint(ctx context.Context, e *model.WebhookEndpoint) error {
+	e.ID = uuid.New()
+	if e.Secret == "" {
+		e.Secret = "whsec_generated"
+	}
+	e.Active = true
+	m.Created = e
+	return nil
+}
+
+func (m *mockWebhookService) ListEndpoints(ctx context.Context) ([]model.WebhookEndpoint, error) {
+	return []model.WebhookEndpoint{{ID: uuid.New(), URL: "https://billing.example.com/hooks", Secret: "whsec_hidden", Active: true}}, nil
+}
+
+func (m *mockWebhookService) GetEndpoint(ctx context.Context, id uuid.UUID) (*model.WebhookEndpoint, error) {
+	return nil, service.ErrWebhookNotFound
+}
+
+func (m *mockWebhookService) DeleteEndpoint(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (m *mockWebhookService) ListDeliveries(ctx context.Context, id uuid.UUID, limit, offset int) ([]model.WebhookDelivery, error) {
+	return []model.WebhookDelivery{}, nil
+}
+
+func (m *mockWebhookService) GetDelivery(ctx context.Context, id, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	return nil, service.ErrDeliveryNotFound
+}
+
+func (m *mockWebhookService) Redeliver(ctx context.Context, id, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	m.Redelivered = deliveryID
+	if m.RedeliverErr != nil {
+		return nil, m.RedeliverErr
+	}
+	return &model.WebhookDelivery{ID: deliveryID, EndpointID: id, Status: model.DeliveryPending, NextAttemptAt: time.Now()}, nil
+}
+
+func (m *mockWebhookService) ForTenant(tenantID string) service.WebhookServiceInterface {
+	m.Tenant = tenantID
+	return m
+}
+
+func newTestWebhookRouter() (*gin.Engine, *mockWebhookService) {
+	gin.SetMode(gin.TestMode)
+	mockSvc := &mockWebhookService{}
+	h := &WebhookHandler{svc: mockSvc, validate: validator.New()}
+	router := gin.New()
+	authenticateAsAdmin(router)
+	h.RegisterRoutes(router)
+	return router, mockSvc
+}
+
+func TestCreateWebhook(t *testing.T) {
+	router, mockSvc := newTestWebhookRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"url":         "https://billing.example.com/hooks",
+		"event_types": []string{"subscription.created", "subscription.expired"},
+	})
+	req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "whsec_generated", resp["secret"])
+	assert.Equal(t, []interface{}{"subscription.created", "subscription.expired"}, resp["event_types"])
+	assert.Equal(t, "https://billing.example.com/hooks", mockSvc.Created.URL)
+}
+
+func TestListWebhooks_HidesSecret(t *testing.T) {
+	router, _ := newTestWebhookRouter()
+
+	req, _ := http.NewRequest("GET", "/webhooks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "whsec_hidden")
+}
+
+func TestRedeliverWebhook(t *testing.T) {
+	router, mockSvc := newTestWebhookRouter()
+
+	deliveryID := uuid.New()
+	req, _ := http.NewRequest("POST", "/webhooks/"+uuid.NewString()+"/deliveries/"+deliveryID.String()+"/redeliver", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, deliveryID, mockSvc.Redelivered)
+
+	mockSvc.RedeliverErr = service.ErrDeliveryInFlight
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code, "ожидали 409 для доставки, которая сейчас отправляется")
+}
+
+func TestCreateWebhook_InvalidEventType(t *testing.T) {
+	router, _ := newTestWebhookRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"url":         "https://billing.example.com/hooks",
+		"event_types": []string{"subscription.renamed"},
+	})
+	req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetWebhookDelivery_NotFound(t *testing.T) {
+	router, _ := newTestWebhookRouter()
+
+	req, _ := http.NewRequest("GET", "/webhooks/"+uuid.NewString()+"/deliveries/"+uuid.NewString(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,147 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// ErrDraining fails the readiness while the server shuts down.
+var ErrDraining = errors.New("server is shutting down")
+
+// CheckFunc reports a problem of a dependency.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one check.
+type CheckResult struct {
+	Status   string `json:"status" example:"ok"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration" example:"1.2ms"`
+}
+
+// Report is the readiness of the service with the result of every check.
+type Report struct {
+	Status string                 `json:"status" example:"ok"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Checker runs the readiness checks, each within timeout and all of them concurrently.
+type Checker struct {
+	timeout  time.Duration
+	names    []string
+	checks   []CheckFunc
+	draining atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a named check; it must be called before the checker is used.
+func (h *Checker) Add(name string, check CheckFunc) *Checker {
+	h.names = append(h.names, name)
+	h.checks = append(h.checks, check)
+	return h
+}
+
+// Drain makes the readiness fail from now on, so that load balancers stop sending requests
+// before the connections are drained.
+func (h *Checker) Drain() {
+	h.draining.Store(true)
+}
+
+// Ready runs the checks. The service is ready when all of them pass and it is not draining.
+func (h *Checker) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(h.checks)+1)}
+	if h.draining.Load() {
+		report.Checks["shutdown"] = CheckResult{Status: StatusFail, Error: ErrDraining.Error()}
+	}
+
+	results := make([]CheckResult, len(h.checks))
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for i, name := range h.names {
+		report.Checks[name] = results[i]
+	}
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+func (h *Checker) run(ctx context.Context, check CheckFunc) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	res := CheckResult{Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status, res.Error = StatusFail, err.Error()
+	}
+	return res
+}
+
+// Ping checks that the database answers.
+func Ping(db *sql.DB) CheckFunc {
+	return db.PingContext
+}
+
+// PoolSaturation fails when at least the given fraction of the pool's maximum connections is in
+// use. A pool without a maximum never saturates.
+func PoolSaturation(db *sql.DB, threshold float64) CheckFunc {
+	return func(ctx context.Context) error {
+		stats := db.Stats()
+		if stats.MaxOpenConnections <= 0 {
+			return nil
+		}
+		if used := float64(stats.InUse) / float64(stats.MaxOpenConnections); used >= threshold {
+			return fmt.Errorf("connection pool saturated: %d of %d connections in use, %d waits",
+				stats.InUse, stats.MaxOpenConnections, stats.WaitCount)
+		}
+		return nil
+	}
+}
+
+// SchemaVersion checks that the migrations are applied up to version, in the schema_migrations
+// table of golang-migrate, and that the last one did not fail halfway.
+func SchemaVersion(db *gorm.DB, version uint) CheckFunc {
+	return func(ctx context.Context) error {
+		var row struct {
+			Version uint
+			Dirty   bool
+		}
+		res := db.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&row)
+		if res.Error != nil {
+			return res.Error
+		}
+		switch {
+		case res.RowsAffected == 0:
+			return fmt.Errorf("no migrations applied, want version %d", version)
+		case row.Dirty:
+			return fmt.Errorf("migration %d failed, schema is dirty", row.Version)
+		case row.Version < version:
+			return fmt.Errorf("schema version %d, want %d", row.Version, version)
+		}
+		return nil
+	}
+}
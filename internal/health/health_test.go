@@ -0,0 +1,35 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestChecker(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	checker := NewChecker(20*time.Millisecond).Add("database", ok).Add("schema", ok)
+	report := checker.Ready(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Len(t, report.Checks, 2)
+
+	checker.Add("broken", func(ctx context.Context) error { return errors.New("boom") }).Add("slow", slow)
+	report = checker.Ready(context.Background())
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, "boom", report.Checks["broken"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error, "ожидали таймаут проверки")
+
+	draining := NewChecker(time.Second).Add("database", ok)
+	draining.Drain()
+	report = draining.Ready(context.Background())
+	assert.Equal(t, StatusFail, report.Status, "ожидали отказ готовности при остановке")
+	assert.Equal(t, ErrDraining.Error(), report.Checks["shutdown"].Error)
+}
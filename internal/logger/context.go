@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type requestIDKey struct{}
+
+// WithRequest returns ctx carrying the request and trace IDs, and a logger that adds them to
+// every line, see Ctx.
+func WithRequest(ctx context.Context, requestID, traceID string) context.Context {
+	l := log.With().Str("request_id", requestID).Str("trace_id", traceID).Logger()
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return l.WithContext(ctx)
+}
+
+// Ctx returns the logger of the request in ctx, or the global logger outside of a request.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+		return l
+	}
+	return &log.Logger
+}
+
+// RequestID returns the ID of the request in ctx, or "" outside of a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
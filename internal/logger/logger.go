@@ -1,37 +1,83 @@
 package logger
 
 import (
+	"REST-service-sub/internal/config"
+	"fmt"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
 	"os"
 	"strings"
 	"time"
 )
 
-func Init(level string) {
+// Init configures the global logger: its level, LOG_FORMAT (json or console) and LOG_OUTPUT
+// (stderr, stdout, or a file rotated by size).
+func Init(cfg *config.Config) error {
 	zerolog.TimeFieldFormat = time.RFC3339
 
-	l := strings.ToLower(strings.TrimSpace(level))
+	if err := SetLevel(cfg.LogLevel); err != nil {
+		return err
+	}
 
-	switch l {
+	var out io.Writer
+	switch cfg.LogOutput {
+	case "stderr":
+		out = os.Stderr
+	case "stdout":
+		out = os.Stdout
+	case "file":
+		out = &lumberjack.Logger{
+			Filename:   cfg.LogFile,
+			MaxSize:    cfg.LogFileMaxSizeMB,
+			MaxBackups: cfg.LogFileMaxBackups,
+			MaxAge:     cfg.LogFileMaxAgeDays,
+			Compress:   cfg.LogFileCompress,
+		}
+	default:
+		return fmt.Errorf("unknown LOG_OUTPUT %q", cfg.LogOutput)
+	}
+
+	switch cfg.LogFormat {
+	case "json":
+		log.Logger = zerolog.New(out).With().Timestamp().Logger()
+	case "console":
+		log.Logger = log.Output(zerolog.ConsoleWriter{
+			Out:        out,
+			NoColor:    out != os.Stderr,
+			TimeFormat: time.RFC3339,
+		})
+	default:
+		return fmt.Errorf("unknown LOG_FORMAT %q", cfg.LogFormat)
+	}
+	return nil
+}
+
+// SetLevel changes the global log level at runtime: debug, info, warn, error, fatal or panic.
+func SetLevel(level string) error {
+	var l zerolog.Level
+	switch strings.ToLower(strings.TrimSpace(level)) {
 	case "debug":
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+		l = zerolog.DebugLevel
 	case "info":
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		l = zerolog.InfoLevel
 	case "warn", "warning":
-		zerolog.SetGlobalLevel(zerolog.WarnLevel)
+		l = zerolog.WarnLevel
 	case "error":
-		zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+		l = zerolog.ErrorLevel
 	case "fatal":
-		zerolog.SetGlobalLevel(zerolog.FatalLevel)
+		l = zerolog.FatalLevel
 	case "panic":
-		zerolog.SetGlobalLevel(zerolog.PanicLevel)
+		l = zerolog.PanicLevel
 	default:
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+		return fmt.Errorf("unknown log level %q", level)
 	}
+	zerolog.SetGlobalLevel(l)
+	return nil
+}
 
-	log.Logger = log.Output(zerolog.ConsoleWriter{
-		Out:        os.Stderr,
-		TimeFormat: time.RFC3339,
-	})
+// Level returns the global log level.
+func Level() string {
+	return zerolog.GlobalLevel().String()
 }
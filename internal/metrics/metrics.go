@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// Registry holds every metric of the service, exposed by Handler.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RequestDuration is labelled by the route template, e.g. /subscriptions/:id, never the raw path.
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	RequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests being served.",
+	}, []string{"method", "route"})
+
+	SubscriptionsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "subscriptions_created_total",
+		Help: "Subscriptions created.",
+	})
+	SubscriptionsUpdated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "subscriptions_updated_total",
+		Help: "Subscriptions updated.",
+	})
+	SubscriptionsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "subscriptions_deleted_total",
+		Help: "Subscriptions deleted.",
+	})
+
+	// ActiveSubscriptions and MonthlyRecurringCost are refreshed periodically, see SetTenantStats.
+	ActiveSubscriptions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "subscriptions_active",
+		Help: "Subscriptions active today.",
+	}, []string{"tenant"})
+	MonthlyRecurringCost = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "subscriptions_monthly_recurring_cost",
+		Help: "Sum of the monthly prices of the active subscriptions.",
+	}, []string{"tenant"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestDuration,
+		RequestsInFlight,
+		SubscriptionsCreated,
+		SubscriptionsUpdated,
+		SubscriptionsDeleted,
+		ActiveSubscriptions,
+		MonthlyRecurringCost,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exports the connection pool statistics of db (go_sql_* metrics).
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// TenantStats are the business gauges of a tenant.
+type TenantStats struct {
+	TenantID    string
+	Active      int64
+	MonthlyCost int64
+}
+
+// SetTenantStats replaces the business gauges, so tenants left without active subscriptions
+// are dropped.
+func SetTenantStats(stats []TenantStats) {
+	ActiveSubscriptions.Reset()
+	MonthlyRecurringCost.Reset()
+	for _, s := range stats {
+		ActiveSubscriptions.WithLabelValues(s.TenantID).Set(float64(s.Active))
+		MonthlyRecurringCost.WithLabelValues(s.TenantID).Set(float64(s.MonthlyCost))
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/auth"
+	"context"
+	"crypto/x509"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+)
+
+const (
+	principalKey = "principal"
+	APIKeyHeader = "X-API-Key"
+)
+
+// APIKeyAuthenticator resolves the caller of an API key, failing with auth.ErrInvalidAPIKey
+// for unknown, expired and revoked keys.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*auth.Principal, error)
+}
+
+// Authenticate identifies the caller by the X-API-Key header or by a bearer token and stores
+// it for the handlers, see Principal. With a nil verifier bearer tokens are not checked and
+// requests without an API key are rejected, only with nil keys as well, i.e. authentication
+// disabled, they pass through anonymously.
+//
+// The subject of a verified TLS client certificate is recorded on the principal. When certRoles
+// are given, a request with such a certificate and without other credentials is authenticated
+// by it alone and gets these roles, see auth.CertPrincipal.
+func Authenticate(v *auth.Verifier, keys APIKeyAuthenticator, certRoles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cert := ClientCert(c)
+		if key := c.GetHeader(APIKeyHeader); key != "" && keys != nil {
+			p, err := keys.Authenticate(c.Request.Context(), key)
+			if errors.Is(err, auth.ErrInvalidAPIKey) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid API key"})
+				return
+			}
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			setPrincipal(c, p, cert)
+			c.Next()
+			return
+		}
+		if cert != nil && len(certRoles) > 0 && c.GetHeader("Authorization") == "" {
+			c.Set(principalKey, auth.CertPrincipal(cert, certRoles))
+			c.Next()
+			return
+		}
+		if v == nil {
+			if keys != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing API key"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			unauthorized(c, "missing bearer token")
+			return
+		}
+		p, err := v.Verify(strings.TrimSpace(token))
+		if err != nil {
+			unauthorized(c, "invalid bearer token")
+			return
+		}
+		setPrincipal(c, p, cert)
+		c.Next()
+	}
+}
+
+func setPrincipal(c *gin.Context, p *auth.Principal, cert *x509.Certificate) {
+	if cert != nil {
+		p.ClientCert = cert.Subject.String()
+	}
+	c.Set(principalKey, p)
+}
+
+// ClientCert returns the verified TLS client certificate of the connection, nil without
+// mutual TLS or when the client sent none.
+func ClientCert(c *gin.Context) *x509.Certificate {
+	if tls := c.Request.TLS; tls != nil && len(tls.VerifiedChains) > 0 && len(tls.VerifiedChains[0]) > 0 {
+		return tls.VerifiedChains[0][0]
+	}
+	return nil
+}
+
+func unauthorized(c *gin.Context, msg string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": msg})
+}
+
+// Require rejects callers lacking the permission with 403 naming it. Anonymous requests,
+// possible only while authentication is disabled, are not checked.
+func Require(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p := Principal(c); p != nil && !p.Can(perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":              "missing permission " + perm,
+				"missing_permission": perm,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Authenticated rejects anonymous requests with 401, for the routes which must stay closed
+// while authentication is disabled.
+func Authenticated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if Principal(c) == nil {
+			unauthorized(c, "authentication required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RoleResolver returns the roles assigned to a user in a tenant.
+type RoleResolver interface {
+	RolesOf(ctx context.Context, tenantID string, userID uuid.UUID) ([]string, error)
+}
+
+// Roles adds the roles assigned in the tenant of the request to those of the bearer token.
+// It has to run after Tenant.
+func Roles(roles RoleResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p := Principal(c); p != nil && p.APIKeyID == nil && p.UserID != uuid.Nil {
+			assigned, err := roles.RolesOf(c.Request.Context(), TenantID(c), p.UserID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			p.Roles = append(p.Roles, assigned...)
+		}
+		c.Next()
+	}
+}
+
+// Principal returns the authenticated caller, nil when authentication is disabled.
+func Principal(c *gin.Context) *auth.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		if p, ok := v.(*auth.Principal); ok {
+			return p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/auth"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret-0123456789"
+
+func newAuthRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	v, err := auth.NewVerifier(auth.Config{Secret: testSecret})
+	require.NoError(t, err)
+	r := gin.New()
+	r.Use(Authenticate(v, nil), Tenant("X-Tenant-ID", false))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, Principal(c).Subject+" "+TenantID(c))
+	})
+	return r
+}
+
+func mintToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return signed
+}
+
+func TestAuthenticate(t *testing.T) {
+	userID := uuid.NewString()
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		auth   string
+		tenant string
+		code   int
+		body   string
+	}{
+		{"no token", "", "", http.StatusUnauthorized, ""},
+		{"not bearer", "Basic dXNlcjpwYXNz", "", http.StatusUnauthorized, ""},
+		{"invalid token", "Bearer abc.def.ghi", "", http.StatusUnauthorized, ""},
+		{"valid", "Bearer " + mintToken(t, jwt.MapClaims{"sub": userID, "exp": exp}), "", http.StatusOK, userID + " default"},
+		{"tenant from token", "Bearer " + mintToken(t, jwt.MapClaims{"sub": userID, "exp": exp, "tenant": "acme"}), "", http.StatusOK, userID + " acme"},
+		{"tenant header matches", "bearer " + mintToken(t, jwt.MapClaims{"sub": userID, "exp": exp, "tenant": "acme"}), "acme", http.StatusOK, userID + " acme"},
+		{"tenant header mismatch", "Bearer " + mintToken(t, jwt.MapClaims{"sub": userID, "exp": exp, "tenant": "acme"}), "globex", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant-ID", tt.tenant)
+			}
+			w := httptest.NewRecorder()
+			newAuthRouter(t).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+			if tt.code == http.StatusUnauthorized {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+type fakeKeys map[string]*auth.Principal
+
+func (f fakeKeys) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	if p, ok := f[key]; ok {
+		return p, nil
+	}
+	return nil, auth.ErrInvalidAPIKey
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyID := uuid.New()
+	keys := fakeKeys{"sk_reader": {TenantID: "acme", APIKeyID: &keyID, Scopes: []string{auth.PermSubscriptionsRead}}}
+
+	r := gin.New()
+	// API keys work without a JWT verifier
+	r.Use(Authenticate(nil, keys), Tenant("X-Tenant-ID", false))
+	r.GET("/read", Require(auth.PermSubscriptionsRead), func(c *gin.Context) {
+		c.String(http.StatusOK, TenantID(c))
+	})
+	r.POST("/write", Require(auth.PermSubscriptionsWrite), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	serve := func(method, target, key string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/read", "sk_reader")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme", w.Body.String())
+
+	w = serve("POST", "/write", "sk_reader")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.JSONEq(t, `{"error":"missing permission subscriptions:write","missing_permission":"subscriptions:write"}`, w.Body.String())
+
+	w = serve("GET", "/read", "sk_unknown")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// without credentials the request is rejected, scopes cannot be bypassed by leaving out the key
+	w = serve("POST", "/write", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "ожидали 401 без ключа")
+
+	// only with authentication disabled altogether the request is anonymous
+	open := gin.New()
+	open.Use(Authenticate(nil, nil))
+	open.POST("/write", Require(auth.PermSubscriptionsWrite), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	req, _ := http.NewRequest("POST", "/write", nil)
+	w = httptest.NewRecorder()
+	open.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+type fakeRoles map[uuid.UUID][]string
+
+func (f fakeRoles) RolesOf(ctx context.Context, tenantID string, userID uuid.UUID) ([]string, error) {
+	if tenantID != "acme" {
+		return nil, nil
+	}
+	return f[userID], nil
+}
+
+func TestRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, err := auth.NewVerifier(auth.Config{Secret: testSecret})
+	require.NoError(t, err)
+	auditor, member := uuid.New(), uuid.New()
+
+	r := gin.New()
+	r.Use(Authenticate(v, nil), Tenant("X-Tenant-ID", false), Roles(fakeRoles{auditor: {auth.RoleAuditor}}))
+	r.GET("/history", Require(auth.PermHistoryRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(userID uuid.UUID, tenantID string) int {
+		req, _ := http.NewRequest("GET", "/history", nil)
+		req.Header.Set("Authorization", "Bearer "+mintToken(t, jwt.MapClaims{"sub": userID.String(), "exp": time.Now().Add(time.Hour).Unix()}))
+		req.Header.Set("X-Tenant-ID", tenantID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve(auditor, "acme"))
+	assert.Equal(t, http.StatusForbidden, serve(auditor, "globex"), "roles are assigned per tenant")
+	assert.Equal(t, http.StatusForbidden, serve(member, "acme"))
+}
+
+func TestAuthenticate_ClientCert(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	v, err := auth.NewVerifier(auth.Config{Secret: testSecret})
+	require.NoError(t, err)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "billing-service", Organization: []string{"Acme"}}}
+
+	newRouter := func(certRoles ...string) *gin.Engine {
+		r := gin.New()
+		r.Use(Authenticate(v, nil, certRoles...), Tenant("X-Tenant-ID", false))
+		r.GET("/aggregate", Require(auth.PermAggregateRead), func(c *gin.Context) {
+			p := Principal(c)
+			c.String(http.StatusOK, p.Subject+"|"+p.ClientCert)
+		})
+		return r
+	}
+	serve := func(r *gin.Engine, withCert bool, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/aggregate", nil)
+		if withCert {
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(newRouter(auth.RoleFinance), true, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "CN=billing-service,O=Acme|CN=billing-service,O=Acme", w.Body.String())
+
+	w = serve(newRouter(), true, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "без TLS_CLIENT_CERT_ROLES сертификат не заменяет токен")
+
+	w = serve(newRouter(auth.RoleFinance), false, "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// a token takes precedence, the certificate is recorded along
+	userID := uuid.NewString()
+	token := mintToken(t, jwt.MapClaims{"sub": userID, "exp": time.Now().Add(time.Hour).Unix()})
+	w = serve(newRouter(auth.RoleFinance), true, token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, userID+"|CN=billing-service,O=Acme", w.Body.String())
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+const (
+	RequestIDHeader   = "X-Request-ID"
+	TraceparentHeader = "traceparent"
+
+	requestIDKey       = "request_id"
+	maxRequestIDLength = 128
+)
+
+// Correlation accepts the X-Request-ID and W3C traceparent headers of the request, or generates
+// them, and echoes them in the response. The request context gets a logger adding both IDs to
+// every line, see logger.Ctx, so it has to run before any middleware that logs. When tracing is
+// enabled it has to run after the tracing middleware, whose server span gives the trace ID.
+func Correlation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		var traceID, spanID, flags string
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() && !sc.IsRemote() {
+			// the server span started by the tracing middleware
+			traceID, spanID, flags = sc.TraceID().String(), sc.SpanID().String(), sc.TraceFlags().String()
+		} else {
+			var ok bool
+			traceID, flags, ok = parseTraceparent(c.GetHeader(TraceparentHeader))
+			if !ok {
+				traceID, flags = randomHex(16), "01"
+			}
+			spanID = randomHex(8)
+		}
+
+		c.Set(requestIDKey, id)
+		c.Request = c.Request.WithContext(logger.WithRequest(c.Request.Context(), id, traceID))
+		c.Header(RequestIDHeader, id)
+		// the span of this server within the trace
+		c.Header(TraceparentHeader, "00-"+traceID+"-"+spanID+"-"+flags)
+		c.Next()
+	}
+}
+
+// RequestID returns the ID of the request, see Correlation.
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceparent returns the trace ID and flags of a version 00 traceparent header.
+func parseTraceparent(h string) (traceID, flags string, ok bool) {
+	parts := strings.Split(h, "-")
+	if len(parts) != 4 || parts[0] != "00" {
+		return "", "", false
+	}
+	traceID, parentID, flags := parts[1], parts[2], parts[3]
+	if !lowerHex(traceID, 32) || !lowerHex(parentID, 16) || !lowerHex(flags, 2) ||
+		strings.Trim(traceID, "0") == "" || strings.Trim(parentID, "0") == "" {
+		return "", "", false
+	}
+	return traceID, flags, true
+}
+
+func lowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/logger"
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCorrelation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	global := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = global }()
+
+	r := gin.New()
+	r.Use(Correlation())
+	r.GET("/", func(c *gin.Context) {
+		assert.Equal(t, RequestID(c), logger.RequestID(c.Request.Context()))
+		logger.Ctx(c.Request.Context()).Info().Msg("handled")
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(map[string]string{
+		RequestIDHeader:   "req-42",
+		TraceparentHeader: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	assert.Equal(t, "req-42", w.Header().Get(RequestIDHeader))
+	traceparent := strings.Split(w.Header().Get(TraceparentHeader), "-")
+	if assert.Len(t, traceparent, 4) {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceparent[1])
+		assert.NotEqual(t, "00f067aa0ba902b7", traceparent[2])
+		assert.Equal(t, "01", traceparent[3])
+	}
+	assert.Contains(t, buf.String(), `"request_id":"req-42"`)
+	assert.Contains(t, buf.String(), `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+
+	// invalid headers are replaced
+	w = serve(map[string]string{
+		RequestIDHeader:   "bad id",
+		TraceparentHeader: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+	})
+	assert.NotEqual(t, "bad id", w.Header().Get(RequestIDHeader), "ожидали новый request id")
+	assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+	assert.NotContains(t, w.Header().Get(TraceparentHeader), "-00000000000000000000000000000000-")
+
+	w = serve(nil)
+	assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+	_, _, ok := parseTraceparent(w.Header().Get(TraceparentHeader))
+	assert.True(t, ok)
+}
+
+func TestCorrelation_TracingSpan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	provider := sdktrace.NewTracerProvider()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	var spanContext trace.SpanContext
+	r := gin.New()
+	r.Use(otelgin.Middleware("test", otelgin.WithTracerProvider(provider)))
+	r.Use(Correlation())
+	r.GET("/", func(c *gin.Context) {
+		spanContext = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.TraceID().String())
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+spanContext.SpanID().String()+"-01", w.Header().Get(TraceparentHeader),
+		"ожидали span id серверного спана")
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// corsExposedHeaders are the response headers of the API a browser script may read.
+var corsExposedHeaders = strings.Join([]string{
+	RequestIDHeader, TraceparentHeader, "Retry-After",
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Idempotent-Replayed",
+}, ", ")
+
+// CORSPolicy lists the origins allowed to call the API from a browser. It may be changed while
+// in use, see SetOrigins.
+type CORSPolicy struct {
+	origins atomic.Pointer[map[string]bool]
+	maxAge  string
+}
+
+// NewCORSPolicy allows the origins, e.g. https://app.example.com, or every origin with "*".
+// Only the listed origins may send credentials such as client certificates.
+// Preflight responses may be cached by the browser for maxAge.
+func NewCORSPolicy(origins []string, maxAge time.Duration) *CORSPolicy {
+	p := &CORSPolicy{maxAge: strconv.Itoa(int(maxAge.Seconds()))}
+	p.SetOrigins(origins)
+	return p
+}
+
+// SetOrigins replaces the allowed origins. Without origins cross-origin requests get no
+// CORS headers and are blocked by the browser.
+func (p *CORSPolicy) SetOrigins(origins []string) {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[strings.TrimRight(origin, "/")] = true
+	}
+	p.origins.Store(&allowed)
+}
+
+// Allowed reports whether a browser script of the origin may call the API.
+func (p *CORSPolicy) Allowed(origin string) bool {
+	allowed := *p.origins.Load()
+	return allowed["*"] || allowed[origin]
+}
+
+func (p *CORSPolicy) listed(origin string) bool {
+	return (*p.origins.Load())[origin]
+}
+
+// CORS answers preflight requests and adds the CORS headers to the responses to allowed
+// origins. It has to run before Authenticate: preflight requests carry no credentials.
+func CORS(p *CORSPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !p.Allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		if p.listed(origin) {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			// any origin, but without the cookies and client certificates of the browser
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+		if !preflight {
+			h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+			c.Next()
+			return
+		}
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+		if headers := c.GetHeader("Access-Control-Request-Headers"); headers != "" {
+			h.Set("Access-Control-Allow-Headers", headers)
+		}
+		h.Set("Access-Control-Max-Age", p.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// Enabled serves the route only while the toggle is on, it is not found otherwise.
+func Enabled(toggle *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !toggle.Load() {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.Next()
+	}
+}
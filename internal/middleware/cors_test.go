@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := NewCORSPolicy([]string{"https://app.example.com/"}, 10*time.Minute)
+	r := gin.New()
+	r.Use(CORS(policy))
+	r.GET("/subscriptions", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/subscriptions", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "GET")
+			req.Header.Set("Access-Control-Request-Headers", "authorization, x-tenant-id")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "https://app.example.com", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "RateLimit-Remaining")
+
+	// the preflight is answered before the routes, OPTIONS is not registered
+	w = serve("OPTIONS", "https://app.example.com", true)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "authorization, x-tenant-id", w.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+	w = serve("GET", "https://evil.example.com", false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusForbidden, serve("OPTIONS", "https://evil.example.com", true).Code)
+
+	w = serve("GET", "", false)
+	assert.Empty(t, w.Header().Get("Vary"), "запросы без Origin не затрагиваются")
+
+	// origins are replaced at runtime; a wildcard allows everyone, without credentials
+	policy.SetOrigins([]string{"*"})
+	w = serve("GET", "https://evil.example.com", false)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+
+	policy.SetOrigins(nil)
+	assert.Empty(t, serve("GET", "https://app.example.com", false).Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestEnabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var toggle atomic.Bool
+	r := gin.New()
+	r.GET("/swagger", Enabled(&toggle), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func() int {
+		req, _ := http.NewRequest("GET", "/swagger", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusNotFound, serve())
+	toggle.Store(true)
+	assert.Equal(t, http.StatusOK, serve())
+}
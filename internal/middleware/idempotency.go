@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/logger"
+	"REST-service-sub/internal/model"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"time"
+)
+
+// IdempotencyKeyHeader is the header a client sets to make a POST request safe to retry.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyStore keeps the requests made with an idempotency key, see service.IdempotencyService.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, rec *model.IdempotencyKey, now time.Time) (*model.IdempotencyKey, error)
+	Complete(ctx context.Context, rec *model.IdempotencyKey) error
+	Release(ctx context.Context, rec *model.IdempotencyKey) error
+}
+
+// Idempotency makes the POST requests to the given routes, made with an Idempotency-Key header,
+// run at most once per key within ttl. A retry with the same body gets the stored response with
+// Idempotent-Replayed: true, a retry with another body gets 422, and a retry while the first
+// request is still running gets 409. Keys are scoped to the tenant and the client, so it has to
+// run after Authenticate and Tenant. Server errors are not stored, the request may be retried.
+func Idempotency(store IdempotencyStore, ttl time.Duration, routes ...string) gin.HandlerFunc {
+	enabled := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		enabled[route] = true
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || c.Request.Method != http.MethodPost || !enabled[c.FullPath()] {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		rec := &model.IdempotencyKey{
+			TenantID:    TenantID(c),
+			Client:      rateLimitClient(c),
+			Key:         key,
+			Fingerprint: requestFingerprint(c.Request, body),
+			ExpiresAt:   now.Add(ttl),
+		}
+		existing, err := store.Begin(c.Request.Context(), rec, now)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if existing != nil {
+			replay(c, rec, existing)
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			// the outcome is stored even when the request was cancelled or timed out
+			ctx := context.WithoutCancel(c.Request.Context())
+			// a panic, a server error or a cancelled request leaves the key free for a retry
+			status := w.Status()
+			if p := recover(); p != nil || status >= http.StatusInternalServerError || status == StatusClientClosedRequest {
+				if err := store.Release(ctx, rec); err != nil {
+					logger.Ctx(c.Request.Context()).Error().Err(err).Str("key", key).Msg("failed to release idempotency key")
+				}
+				if p != nil {
+					panic(p)
+				}
+				return
+			}
+			rec.StatusCode = &status
+			rec.ContentType = w.Header().Get("Content-Type")
+			rec.Response = w.body.Bytes()
+			if err := store.Complete(ctx, rec); err != nil {
+				logger.Ctx(c.Request.Context()).Error().Err(err).Str("key", key).Msg("failed to store idempotent response")
+			}
+		}()
+		c.Next()
+	}
+}
+
+func replay(c *gin.Context, rec, existing *model.IdempotencyKey) {
+	switch {
+	case existing.Fingerprint != rec.Fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with another request"})
+	case existing.StatusCode == nil:
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is in progress"})
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(*existing.StatusCode, existing.ContentType, existing.Response)
+		c.Abort()
+	}
+}
+
+// requestFingerprint identifies the request made with a key: its method, URI and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/model"
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]model.IdempotencyKey
+}
+
+func (s *fakeIdempotencyStore) id(rec *model.IdempotencyKey) string {
+	return rec.TenantID + "|" + rec.Client + "|" + rec.Key
+}
+
+func (s *fakeIdempotencyStore) Begin(ctx context.Context, rec *model.IdempotencyKey, now time.Time) (*model.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.keys[s.id(rec)]; ok && existing.ExpiresAt.After(now) {
+		return &existing, nil
+	}
+	s.keys[s.id(rec)] = *rec
+	return nil, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(ctx context.Context, rec *model.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[s.id(rec)] = *rec
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(ctx context.Context, rec *model.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, s.id(rec))
+	return nil
+}
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeIdempotencyStore{keys: map[string]model.IdempotencyKey{}}
+	created := 0
+	failStatus := http.StatusInternalServerError
+
+	r := gin.New()
+	r.Use(Idempotency(store, time.Hour, "/subscriptions", "/flaky"))
+	r.POST("/subscriptions", func(c *gin.Context) {
+		created++
+		c.JSON(http.StatusCreated, gin.H{"n": created})
+	})
+	r.POST("/flaky", func(c *gin.Context) {
+		if failStatus != 0 {
+			c.JSON(failStatus, gin.H{"error": "boom"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/other", func(c *gin.Context) {
+		created++
+		c.Status(http.StatusOK)
+	})
+
+	serve := func(path, key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/subscriptions", "k1", `{"price":100}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"n":1}`, w.Body.String())
+
+	w = serve("/subscriptions", "k1", `{"price":100}`)
+	assert.Equal(t, http.StatusCreated, w.Code, "ожидали сохранённый ответ")
+	assert.JSONEq(t, `{"n":1}`, w.Body.String())
+	assert.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, 1, created)
+
+	w = serve("/subscriptions", "k1", `{"price":200}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "ожидали 422 для другого тела")
+
+	// without a key, or on another route, requests are not deduplicated
+	serve("/subscriptions", "", `{"price":100}`)
+	serve("/other", "k1", "")
+	serve("/other", "k1", "")
+	assert.Equal(t, 4, created)
+
+	// a server error frees the key
+	assert.Equal(t, http.StatusInternalServerError, serve("/flaky", "k2", "").Code)
+	// and so does a request given up by its client
+	failStatus = StatusClientClosedRequest
+	assert.Equal(t, StatusClientClosedRequest, serve("/flaky", "k2", "").Code)
+	failStatus = 0
+	assert.Equal(t, http.StatusNoContent, serve("/flaky", "k2", "").Code)
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeIdempotencyStore{keys: map[string]model.IdempotencyKey{}}
+	started, release := make(chan struct{}), make(chan struct{})
+
+	r := gin.New()
+	r.Use(Idempotency(store, time.Hour, "/subscriptions"))
+	r.POST("/subscriptions", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/subscriptions", bytes.NewBufferString(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve() }()
+	<-started
+
+	w := serve()
+	assert.Equal(t, http.StatusConflict, w.Code, "ожидали 409, пока первый запрос выполняется")
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
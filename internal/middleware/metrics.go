@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels the requests to unknown paths, so that they don't create a series each.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the duration and the number in flight of the requests, by route template.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		inFlight := metrics.RequestsInFlight.WithLabelValues(c.Request.Method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+		metrics.RequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/metrics"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Metrics())
+	r.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	for _, path := range []string{"/items/1", "/items/2", "/missing/3"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	body := w.Body.String()
+
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/items/:id",status="200"} 2`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="unmatched",status="404"} 1`)
+	assert.False(t, strings.Contains(body, "/items/1"), "ожидали шаблон маршрута вместо пути")
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.RequestsInFlight.WithLabelValues("GET", "/items/:id")))
+}
@@ -1,17 +1,30 @@
 package middleware
 
 import (
+	"REST-service-sub/internal/logger"
 	"github.com/gin-gonic/gin"
-	"github.com/rs/zerolog/log"
+	"github.com/rs/zerolog"
 	"time"
 )
 
-func RequestLogger() gin.HandlerFunc {
+// RequestLogger logs every request. With sampleEvery > 1 only one of every sampleEvery
+// successful requests is logged; failed requests are always logged.
+func RequestLogger(sampleEvery int) gin.HandlerFunc {
+	var sampler zerolog.Sampler
+	if sampleEvery > 1 {
+		sampler = &zerolog.BasicSampler{N: uint32(sampleEvery)}
+	}
 	return func(c *gin.Context) {
 		start := time.Now()
 		c.Next()
 		latency := time.Since(start)
-		log.Info().
+
+		l := logger.Ctx(c.Request.Context())
+		if sampler != nil && c.Writer.Status() < 400 {
+			sampled := l.Sample(sampler)
+			l = &sampled
+		}
+		l.Info().
 			Str("method", c.Request.Method).
 			Str("path", c.Request.URL.Path).
 			Int("status", c.Writer.Status()).
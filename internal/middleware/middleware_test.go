@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestLogger_Sampling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	global := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = global }()
+
+	r := gin.New()
+	r.Use(RequestLogger(3))
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/fail", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	for i := 0; i < 6; i++ {
+		req, _ := http.NewRequest("GET", "/ok", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/fail", nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"), "ожидали, что ошибки логируются без сэмплирования")
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/logger"
+	"REST-service-sub/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimit limits the requests of every client, identified by its API key, its user, its TLS
+// client certificate or its IP address, in that order. It has to run after Authenticate and
+// Tenant. The state of the client's bucket is sent in RateLimit-* headers, a client out of tokens
+// gets 429 with Retry-After.
+// When the store fails or the limiter is disabled the request is let through.
+func RateLimit(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.Enabled() {
+			c.Next()
+			return
+		}
+		res, err := l.Take(c.Request.Context(), c.FullPath(), rateLimitClient(c))
+		limitRequest(c, res, err)
+	}
+}
+
+// RateLimitIP limits the requests of every IP address in the class, whatever their route. It has to
+// run before Authenticate, so that requests with wrong credentials, rejected there, are limited too.
+func RateLimitIP(l *ratelimit.Limiter, class string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.Enabled() {
+			c.Next()
+			return
+		}
+		res, err := l.TakeClass(c.Request.Context(), class, "ip:"+c.ClientIP())
+		limitRequest(c, res, err)
+	}
+}
+
+func limitRequest(c *gin.Context, res ratelimit.Result, err error) {
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Warn().Err(err).Msg("rate limit store failed, request not limited")
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+	if !res.Allowed {
+		c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+		return
+	}
+	c.Next()
+}
+
+func rateLimitClient(c *gin.Context) string {
+	if p := Principal(c); p != nil {
+		if p.APIKeyID != nil {
+			return "key:" + p.APIKeyID.String()
+		}
+		if p.UserID == uuid.Nil && p.ClientCert != "" {
+			return "cert:" + p.ClientCert
+		}
+		return "user:" + TenantID(c) + ":" + p.UserID.String()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Limit{PerMinute: 60, Burst: 2}).
+		Class("expensive", ratelimit.Limit{PerMinute: 6, Burst: 1}, "/aggregate")
+
+	r := gin.New()
+	r.Use(RateLimit(limiter))
+	r.GET("/list", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/aggregate", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(path, ip string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":40000"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/list", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusOK, serve("/list", "10.0.0.1").Code)
+	w = serve("/list", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+
+	// another client, and another class of the same client, are counted separately
+	assert.Equal(t, http.StatusOK, serve("/list", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusOK, serve("/aggregate", "10.0.0.1").Code)
+	w = serve("/aggregate", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+
+	// turned off at runtime, the limiter lets everything through without headers
+	limiter.SetEnabled(false)
+	w = serve("/aggregate", "10.0.0.1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitIP_BeforeAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := ratelimit.New(ratelimit.NewMemoryStore(), ratelimit.Limit{PerMinute: 60, Burst: 10}).
+		Class("ip", ratelimit.Limit{PerMinute: 6, Burst: 2})
+
+	r := gin.New()
+	r.Use(RateLimitIP(limiter, "ip"), Authenticate(nil, fakeKeys{}))
+	r.GET("/list", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(ip string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/list", nil)
+		req.RemoteAddr = ip + ":40000"
+		req.Header.Set(APIKeyHeader, "sk_guess")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve("10.0.0.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("10.0.0.1").Code)
+	w := serve("10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code, "подбор ключей с одного адреса ограничен")
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusUnauthorized, serve("10.0.0.2").Code)
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const tenantKey = "tenant_id"
+
+// Tenant resolves the tenant of the request from the header. Without the header the request
+// belongs to tenant.Default, or is rejected with 400 when required is set.
+// A bearer token naming a tenant pins the request to it: the header may be omitted,
+// and a header naming another tenant is rejected with 403.
+func Tenant(header string, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(header)
+		if p := Principal(c); p != nil && p.TenantID != "" {
+			if id != "" && id != p.TenantID {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "token is not valid for tenant " + id})
+				return
+			}
+			id = p.TenantID
+		}
+		switch {
+		case id == "" && required:
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": header + " header is required"})
+			return
+		case id == "":
+			id = tenant.Default
+		case !tenant.Valid(id):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + header})
+			return
+		}
+		c.Set(tenantKey, id)
+		c.Next()
+	}
+}
+
+// TenantID returns the tenant resolved for the request, tenant.Default when none was.
+func TenantID(c *gin.Context) string {
+	if id := c.GetString(tenantKey); id != "" {
+		return id
+	}
+	return tenant.Default
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"REST-service-sub/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTenantRouter(required bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Tenant("X-Tenant-ID", required))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, TenantID(c))
+	})
+	return r
+}
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		required bool
+		code     int
+		tenant   string
+	}{
+		{"from header", "acme", false, http.StatusOK, "acme"},
+		{"default tenant", "", false, http.StatusOK, tenant.Default},
+		{"required", "", true, http.StatusBadRequest, ""},
+		{"invalid", "acme corp;drop", false, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			newTenantRouter(tt.required).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.code, w.Code)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, tt.tenant, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// StatusClientClosedRequest is the non-standard status, borrowed from nginx, of a request whose
+// client went away before the response was ready.
+const StatusClientClosedRequest = 499
+
+// Timeouts bounds how long the queries of a request may run: a default for every route and
+// other limits for some routes, e.g. longer ones for aggregates.
+type Timeouts struct {
+	def    time.Duration
+	routes map[string]time.Duration
+}
+
+// NewTimeouts limits every route to def, zero means no limit.
+func NewTimeouts(def time.Duration) *Timeouts {
+	return &Timeouts{def: def, routes: map[string]time.Duration{}}
+}
+
+// Route limits the routes, as registered, e.g. /subscriptions/:id, to d instead of the default.
+// Zero means no limit, for streams.
+func (t *Timeouts) Route(d time.Duration, routes ...string) *Timeouts {
+	for _, route := range routes {
+		t.routes[route] = d
+	}
+	return t
+}
+
+// For returns the limit of the route.
+func (t *Timeouts) For(route string) time.Duration {
+	if d, ok := t.routes[route]; ok {
+		return d
+	}
+	return t.def
+}
+
+// Timeout sets the deadline of the route on the request context, which the services pass on
+// to the database. A request past its deadline gets 504, see handler.respondWithError.
+func Timeout(t *Timeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := t.For(c.FullPath())
+		if d <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
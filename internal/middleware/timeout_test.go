@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	timeouts := NewTimeouts(time.Second).
+		Route(time.Minute, "/aggregate").
+		Route(0, "/events")
+	assert.Equal(t, time.Second, timeouts.For("/items/:id"))
+	assert.Equal(t, time.Minute, timeouts.For("/aggregate"))
+
+	r := gin.New()
+	r.Use(Timeout(timeouts))
+	remaining := func(c *gin.Context) {
+		deadline, ok := c.Request.Context().Deadline()
+		if !ok {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, time.Until(deadline).Round(time.Second).String())
+	}
+	r.GET("/items/:id", remaining)
+	r.GET("/aggregate", remaining)
+	r.GET("/events", remaining)
+
+	for path, want := range map[string]string{
+		"/items/1":   "1s",
+		"/aggregate": "1m0s",
+		"/events":    "none",
+	} {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, want, w.Body.String(), path)
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APIKey grants non-interactive access with a fixed set of scopes. Only the hash of the key is
+// stored, Prefix is its first characters so that keys can be told apart.
+type APIKey struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID   string         `gorm:"type:text;not null" json:"-"`
+	Name       string         `gorm:"type:text;not null" json:"name"`
+	Prefix     string         `gorm:"type:text;not null" json:"prefix"`
+	Hash       string         `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	Scopes     pq.StringArray `gorm:"type:text[];not null" json:"scopes"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Usable reports whether the key is neither revoked nor expired at now.
+func (k *APIKey) Usable(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
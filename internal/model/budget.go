@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Budget is a monthly spending limit of a user. It covers all subscriptions of the user,
+// or only one category (see Service.Category) or one service when Category or ServiceName is set.
+type Budget struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID     string    `gorm:"type:text;not null" json:"-"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Category     *string   `gorm:"type:text" json:"category,omitempty"`
+	ServiceName  *string   `gorm:"type:text" json:"service_name,omitempty"`
+	MonthlyLimit int       `gorm:"not null" json:"monthly_limit"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BudgetAlert records that a budget was exceeded in a month. At most one alert exists per budget and month.
+type BudgetAlert struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID     string    `gorm:"type:text;not null" json:"-"`
+	BudgetID     uuid.UUID `gorm:"type:uuid;not null" json:"budget_id"`
+	UserID       uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Period       time.Time `gorm:"type:date;not null" json:"period"`
+	MonthlyLimit int       `gorm:"not null" json:"monthly_limit"`
+	Spent        int64     `gorm:"not null" json:"spent"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventSubscriptionExpired = "subscription.expired"
+
+	EventSubscriptionPaused    = "subscription.paused"
+	EventSubscriptionResumed   = "subscription.resumed"
+	EventSubscriptionCancelled = "subscription.cancelled"
+)
+
+// EventTypes lists every subscription lifecycle event type.
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionExpired,
+	EventSubscriptionPaused,
+	EventSubscriptionResumed,
+	EventSubscriptionCancelled,
+}
+
+// SubscriptionEvent is an entry of the subscription change log. It is written in the same
+// transaction as the change itself and serves as the outbox for webhook deliveries.
+type SubscriptionEvent struct {
+	ID             int64           `gorm:"primaryKey" json:"id"`
+	TenantID       string          `gorm:"type:text;not null" json:"-"`
+	Type           string          `gorm:"type:text;not null" json:"type"`
+	SubscriptionID uuid.UUID       `gorm:"type:uuid;not null" json:"subscription_id"`
+	UserID         uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Payload        json.RawMessage `gorm:"type:jsonb;not null" json:"data"`
+	CreatedAt      time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
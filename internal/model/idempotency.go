@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a request made with an Idempotency-Key header. StatusCode is nil while the
+// first request with the key is being processed, then the response is kept until ExpiresAt.
+type IdempotencyKey struct {
+	TenantID    string    `gorm:"type:text;primaryKey"`
+	Client      string    `gorm:"type:text;primaryKey"`
+	Key         string    `gorm:"type:text;primaryKey"`
+	Fingerprint string    `gorm:"type:text;not null"`
+	StatusCode  *int      `gorm:"type:integer"`
+	ContentType string    `gorm:"type:text;not null"`
+	Response    []byte    `gorm:"type:bytea"`
+	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	ExpiresAt   time.Time `gorm:"not null"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubscriptionMember shares the cost of a subscription paid by Subscription.UserID.
+// A member pays either a fixed monthly Amount or a Weight-proportional part of what is left
+// of the price after fixed amounts; the payer covers the rest.
+type SubscriptionMember struct {
+	SubscriptionID uuid.UUID `gorm:"type:uuid;primaryKey" json:"subscription_id"`
+	TenantID       string    `gorm:"type:text;not null" json:"-"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Weight         *int      `json:"weight,omitempty"`
+	Amount         *int      `json:"amount,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -6,13 +6,63 @@ import (
 	"github.com/google/uuid"
 )
 
+// Stored subscription statuses, changed by pause, resume and cancel.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+)
+
+// Derived statuses, computed from the dates of a non-cancelled subscription.
+const (
+	StatusScheduled = "scheduled"
+	StatusEnded     = "ended"
+)
+
+// Statuses lists every status usable in the status filter.
+var Statuses = []string{StatusActive, StatusPaused, StatusCancelled, StatusScheduled, StatusEnded}
+
 type Subscription struct {
 	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID    string     `gorm:"type:text;not null" json:"-"`
 	ServiceName string     `gorm:"type:text;not null" json:"service_name" validate:"required"`
 	Price       int        `gorm:"not null" json:"price" validate:"required,min=0"`
 	UserID      uuid.UUID  `gorm:"type:uuid;not null" json:"user_id" validate:"required"`
 	StartDate   time.Time  `gorm:"type:date;not null" json:"start_date"`
 	EndDate     *time.Time `gorm:"type:date" json:"end_date,omitempty"`
+	Status      string     `gorm:"type:text;not null;default:active" json:"status"`
 	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
+
+// SubscriptionPause is an interval during which a subscription is not charged.
+// A month is free when the pause covers all of it; ResumedAt is nil while the pause lasts.
+type SubscriptionPause struct {
+	ID             uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID       string     `gorm:"type:text;not null" json:"-"`
+	SubscriptionID uuid.UUID  `gorm:"type:uuid;not null" json:"subscription_id"`
+	PausedAt       time.Time  `gorm:"type:date;not null" json:"paused_at"`
+	ResumedAt      *time.Time `gorm:"type:date" json:"resumed_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// User is the owner of subscriptions. Subscriptions reference users by id only,
+// so a subscription may belong to a user without a row here.
+type User struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	TenantID  string    `gorm:"type:text;not null" json:"-"`
+	Name      string    `gorm:"type:text;not null" json:"name"`
+	Email     *string   `gorm:"type:text" json:"email,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Service is a catalog entry describing a subscribed service, matched by Subscription.ServiceName.
+type Service struct {
+	Name      string    `gorm:"type:text;primaryKey" json:"name"`
+	TenantID  string    `gorm:"type:text;primaryKey" json:"-"`
+	Category  *string   `gorm:"type:text" json:"category,omitempty"`
+	Website   *string   `gorm:"type:text" json:"website,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
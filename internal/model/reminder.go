@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReminderSent marks a reminder as sent, so that it is sent once per subscription, kind and due date
+// regardless of restarts and replicas.
+type ReminderSent struct {
+	SubscriptionID uuid.UUID `gorm:"type:uuid;primaryKey" json:"subscription_id"`
+	TenantID       string    `gorm:"type:text;not null" json:"-"`
+	Kind           string    `gorm:"type:text;primaryKey" json:"kind"`
+	DueDate        time.Time `gorm:"type:date;primaryKey" json:"due_date"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	SentAt         time.Time `gorm:"autoCreateTime" json:"sent_at"`
+}
+
+func (ReminderSent) TableName() string {
+	return "reminders_sent"
+}
@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RoleAssignment grants a role to a user within a tenant, in addition to the roles of the user's token.
+type RoleAssignment struct {
+	TenantID  string    `gorm:"type:text;primaryKey" json:"-"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Role      string    `gorm:"type:text;primaryKey" json:"role"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	DeliveryPending = "pending"
+	// DeliveryDelivering is a delivery leased by a worker that is sending it, until next_attempt_at
+	DeliveryDelivering = "delivering"
+	DeliveryDelivered  = "delivered"
+	DeliveryDead       = "dead"
+)
+
+// WebhookEndpoint receives subscription events. An empty EventTypes means every event type.
+type WebhookEndpoint struct {
+	ID         uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID   string         `gorm:"type:text;not null" json:"-"`
+	URL        string         `gorm:"type:text;not null" json:"url"`
+	Secret     string         `gorm:"type:text;not null" json:"-"`
+	EventTypes pq.StringArray `gorm:"type:text[];not null" json:"event_types"`
+	Active     bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Accepts reports whether the endpoint is subscribed to the event type.
+func (e *WebhookEndpoint) Accepts(eventType string) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range e.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is an outbox entry: one event to be delivered to one endpoint.
+type WebhookDelivery struct {
+	ID            uuid.UUID                `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TenantID      string                   `gorm:"type:text;not null" json:"-"`
+	EndpointID    uuid.UUID                `gorm:"type:uuid;not null" json:"endpoint_id"`
+	EventID       int64                    `gorm:"not null" json:"event_id"`
+	EventType     string                   `gorm:"type:text;not null" json:"event_type"`
+	Status        string                   `gorm:"type:text;not null;default:pending" json:"status"`
+	Attempts      int                      `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time                `gorm:"not null" json:"next_attempt_at"`
+	LastError     *string                  `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt   *time.Time               `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time                `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time                `gorm:"autoUpdateTime" json:"updated_at"`
+	History       []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID" json:"history,omitempty"`
+}
+
+// WebhookDeliveryAttempt records a single HTTP attempt of a delivery.
+type WebhookDeliveryAttempt struct {
+	ID         int64     `gorm:"primaryKey" json:"id"`
+	TenantID   string    `gorm:"type:text;not null" json:"-"`
+	DeliveryID uuid.UUID `gorm:"type:uuid;not null" json:"delivery_id"`
+	Attempt    int       `gorm:"not null" json:"attempt"`
+	StatusCode *int      `json:"status_code,omitempty"`
+	Error      *string   `gorm:"type:text" json:"error,omitempty"`
+	DurationMs int64     `gorm:"not null" json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LogNotifier writes reminders to the application log. Useful locally and as a fallback.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, r Reminder) error {
+	log.Info().
+		Str("kind", r.Kind).
+		Str("subscription_id", r.SubscriptionID.String()).
+		Str("user_id", r.UserID.String()).
+		Time("due_date", r.DueDate).
+		Msg(r.Text())
+	return nil
+}
@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"REST-service-sub/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReminderEndDate    = "end_date"
+	ReminderNextCharge = "next_charge"
+)
+
+// ErrNoRecipient is returned by notifiers that cannot reach the user, e.g. SMTP without an email address.
+var ErrNoRecipient = errors.New("no recipient for reminder")
+
+// Reminder tells a user that a subscription ends or is charged on DueDate.
+type Reminder struct {
+	Kind           string    `json:"kind"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	TenantID       string    `json:"tenant_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	Email          string    `json:"email,omitempty"`
+	ServiceName    string    `json:"service_name"`
+	Price          int       `json:"price"`
+	DueDate        time.Time `json:"due_date"`
+}
+
+// Text is the human readable reminder used by the SMTP and log notifiers.
+func (r Reminder) Text() string {
+	due := r.DueDate.Format("02.01.2006")
+	if r.Kind == ReminderEndDate {
+		return fmt.Sprintf("Your %s subscription ends on %s.", r.ServiceName, due)
+	}
+	return fmt.Sprintf("Your %s subscription will be charged %d RUB on %s.", r.ServiceName, r.Price, due)
+}
+
+// Notifier delivers reminders to users.
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// New builds the notifier selected by cfg.Notifier: log (default), smtp or webhook.
+func New(cfg *config.Config) (Notifier, error) {
+	switch strings.ToLower(cfg.Notifier) {
+	case "", "log":
+		return LogNotifier{}, nil
+	case "smtp":
+		if cfg.SMTPHost == "" || cfg.SMTPFrom == "" {
+			return nil, fmt.Errorf("smtp notifier requires SMTP_HOST and SMTP_FROM")
+		}
+		return &SMTPNotifier{
+			Addr:     cfg.SMTPHost + ":" + cfg.SMTPPort,
+			From:     cfg.SMTPFrom,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			Timeout:  cfg.NotifyTimeout,
+		}, nil
+	case "webhook":
+		if cfg.NotifyWebhookURL == "" {
+			return nil, fmt.Errorf("webhook notifier requires NOTIFY_WEBHOOK_URL")
+		}
+		return NewWebhookNotifier(cfg.NotifyWebhookURL, cfg.NotifyTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", cfg.Notifier)
+	}
+}
@@ -0,0 +1,171 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer accepts a single session and records the envelope and the message.
+type fakeSMTPServer struct {
+	addr string
+	done chan struct{}
+	from string
+	to   []string
+	data string
+}
+
+func startFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTPServer{addr: ln.Addr().String(), done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		upper := strings.ToUpper(cmd)
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.from = strings.Trim(strings.Fields(cmd[len("MAIL FROM:"):])[0], "<>")
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			s.to = append(s.to, strings.Trim(strings.Fields(cmd[len("RCPT TO:"):])[0], "<>"))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.data = b.String()
+			reply("250 OK queued")
+		case upper == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func testReminder() Reminder {
+	return Reminder{
+		Kind:           ReminderNextCharge,
+		SubscriptionID: uuid.New(),
+		UserID:         uuid.New(),
+		Email:          "ivan@example.com",
+		ServiceName:    "Yandex Plus",
+		Price:          400,
+		DueDate:        time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	srv := startFakeSMTPServer(t)
+	n := &SMTPNotifier{Addr: srv.addr, From: "noreply@example.com", Timeout: 5 * time.Second}
+
+	require.NoError(t, n.Notify(context.Background(), testReminder()))
+	<-srv.done
+
+	assert.Equal(t, "noreply@example.com", srv.from)
+	assert.Equal(t, []string{"ivan@example.com"}, srv.to)
+	assert.Contains(t, srv.data, "Subject: Reminder: Yandex Plus\r\n")
+	assert.Contains(t, srv.data, "will be charged 400 RUB on 01.08.2025")
+}
+
+func TestSMTPNotifier_HeaderInjection(t *testing.T) {
+	n := &SMTPNotifier{Addr: "127.0.0.1:1", From: "noreply@example.com"}
+
+	r := testReminder()
+	r.ServiceName = "X\r\nBcc: victim@example.com"
+	assert.ErrorIs(t, n.Notify(context.Background(), r), errLineBreak, "перевод строки в названии сервиса")
+
+	r = testReminder()
+	r.Email = "ivan@example.com\r\nBcc: victim@example.com"
+	assert.ErrorIs(t, n.Notify(context.Background(), r), errLineBreak, "перевод строки в адресе")
+
+	r = testReminder()
+	r.Email = "ivan@example.com, victim@example.com"
+	assert.ErrorContains(t, n.Notify(context.Background(), r), "invalid recipient")
+
+	r = testReminder()
+	r.ServiceName = "Кинопоиск"
+	to, err := mail.ParseAddress(r.Email)
+	require.NoError(t, err)
+	msg := string(n.message(r, to))
+	assert.Contains(t, msg, "Subject: =?utf-8?q?Reminder:_")
+	assert.NotContains(t, msg, "Subject: Reminder: Кинопоиск")
+}
+
+func TestSMTPNotifier_NoRecipient(t *testing.T) {
+	n := &SMTPNotifier{Addr: "127.0.0.1:1", From: "noreply@example.com"}
+	r := testReminder()
+	r.Email = ""
+
+	assert.ErrorIs(t, n.Notify(context.Background(), r), ErrNoRecipient)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Reminder
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	r := testReminder()
+	require.NoError(t, NewWebhookNotifier(srv.URL, time.Second).Notify(context.Background(), r))
+	assert.Equal(t, r.SubscriptionID, got.SubscriptionID)
+	assert.Equal(t, ReminderNextCharge, got.Kind)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	assert.Error(t, NewWebhookNotifier(srv.URL, time.Second).Notify(context.Background(), testReminder()))
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// errLineBreak rejects reminders whose values would inject headers into the message.
+var errLineBreak = errors.New("line break in mail header value")
+
+// SMTPNotifier emails reminders to Reminder.Email. STARTTLS is used when the server offers it,
+// authentication only when Username is set.
+type SMTPNotifier struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+	Timeout  time.Duration
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, r Reminder) error {
+	if r.Email == "" {
+		return ErrNoRecipient
+	}
+	if strings.ContainsAny(r.Email, "\r\n") || strings.ContainsAny(r.ServiceName, "\r\n") {
+		return errLineBreak
+	}
+	to, err := mail.ParseAddress(r.Email)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", r.Email, err)
+	}
+	if n.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, n.Timeout)
+		defer cancel()
+	}
+
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.Username, n.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(r, to)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message builds the mail, the subject is encoded as it holds the user supplied service name.
+func (n *SMTPNotifier) message(r Reminder, to *mail.Address) []byte {
+	subject := fmt.Sprintf("Reminder: %s", r.ServiceName)
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(r.Text())
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
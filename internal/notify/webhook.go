@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WebhookNotifier POSTs reminders as JSON to a generic HTTP endpoint.
+type WebhookNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("reminder webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is how often refilled buckets are dropped.
+const memorySweepInterval = 10 * time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket has refilled to Burst, at the limit of its last request
+	full time.Time
+}
+
+// MemoryStore keeps the buckets in process. Every replica counts on its own.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.swept) > memorySweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = refill(limit, b.tokens, b.updated, now)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	res := result(limit, b.tokens, allowed)
+	if limit.rate() > 0 {
+		b.full = now.Add(res.Reset)
+	} else {
+		b.full = time.Time{}
+	}
+	return res, nil
+}
+
+// sweep drops the buckets that have refilled to Burst. A dropped bucket is recreated full, so
+// dropping a partly drained one would hand its client a fresh burst. Buckets of a limit without
+// refill are kept.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.full.IsZero() && !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.swept = now
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresStore keeps the buckets in the rate_limit_buckets table, so that all replicas share them.
+// A token is taken with a single upsert, concurrent requests of a client are serialized by the row lock.
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// refillSQL is refill in SQL, over the stored row b and the upserted values.
+const refillSQL = `LEAST(CAST(@burst AS float8), b.tokens + GREATEST(0, EXTRACT(EPOCH FROM (EXCLUDED.updated_at - b.updated_at))::float8) * CAST(@rate AS float8))`
+
+var takeSQL = strings.ReplaceAll(`
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (@key, CAST(@burst AS float8) - 1, true, @now)
+ON CONFLICT (key) DO UPDATE SET
+	tokens = CASE WHEN {refill} >= 1 THEN {refill} - 1 ELSE {refill} END,
+	allowed = {refill} >= 1,
+	updated_at = EXCLUDED.updated_at
+RETURNING tokens, allowed`, "{refill}", refillSQL)
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	var row struct {
+		Tokens  float64
+		Allowed bool
+	}
+	err := s.db.WithContext(ctx).Raw(takeSQL, map[string]interface{}{
+		"key":   key,
+		"burst": limit.Burst,
+		"rate":  limit.rate(),
+		"now":   now,
+	}).Scan(&row).Error
+	if err != nil {
+		return Result{}, err
+	}
+	return result(limit, row.Tokens, row.Allowed), nil
+}
+
+// Purge deletes the buckets untouched since before, they would be full again by now.
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Exec("DELETE FROM rate_limit_buckets WHERE updated_at < ?", before)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limit is a token bucket: PerMinute tokens are added every minute up to Burst, a request takes one.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is the wait until the next token, zero when the request was allowed.
+	RetryAfter time.Duration
+	// Reset is the wait until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the buckets. Take refills the bucket of key, then takes a token if one is available.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// refill returns the tokens of a bucket holding tokens at updated, at now.
+func refill(limit Limit, tokens float64, updated, now time.Time) float64 {
+	elapsed := now.Sub(updated).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed*limit.rate())
+}
+
+// result describes a bucket left with tokens after the request.
+func result(limit Limit, tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+	}
+	rate := limit.rate()
+	if rate <= 0 {
+		return r
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	r.Reset = seconds((float64(limit.Burst) - tokens) / rate)
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+const DefaultClass = "default"
+
+// Limiter picks the limit of a request by its route: routes put into a class share its limit,
+// all others fall into DefaultClass. The limits may be changed and the limiter turned off while
+// it is in use.
+type Limiter struct {
+	store    Store
+	disabled atomic.Bool
+
+	mu     sync.RWMutex
+	limits map[string]Limit
+	routes map[string]string
+}
+
+func New(store Store, def Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		limits: map[string]Limit{DefaultClass: def},
+		routes: map[string]string{},
+	}
+}
+
+// Class limits the routes, given as registered in gin (e.g. /subscriptions/:id), separately
+// from the rest. Each class has its own buckets.
+func (l *Limiter) Class(name string, limit Limit, routes ...string) *Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[name] = limit
+	for _, route := range routes {
+		l.routes[route] = name
+	}
+	return l
+}
+
+// FullAfter returns the longest time a bucket of any class takes to refill. Buckets untouched
+// for that long are full and can be forgotten.
+func (l *Limiter) FullAfter() time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var longest time.Duration
+	for _, limit := range l.limits {
+		if rate := limit.rate(); rate > 0 {
+			longest = max(longest, seconds(float64(limit.Burst)/rate))
+		}
+	}
+	return longest
+}
+
+// Take takes a token from the bucket of the client for the route.
+func (l *Limiter) Take(ctx context.Context, route, client string) (Result, error) {
+	l.mu.RLock()
+	class, ok := l.routes[route]
+	if !ok {
+		class = DefaultClass
+	}
+	l.mu.RUnlock()
+	return l.TakeClass(ctx, class, client)
+}
+
+// TakeClass takes a token from the bucket of the client in the class, added with Class, whatever
+// the route of the request.
+func (l *Limiter) TakeClass(ctx context.Context, class, client string) (Result, error) {
+	l.mu.RLock()
+	limit := l.limits[class]
+	l.mu.RUnlock()
+	return l.store.Take(ctx, class+":"+client, limit, time.Now())
+}
+
+// SetLimit changes the limit of a class, DefaultClass included. The buckets keep their tokens.
+func (l *Limiter) SetLimit(class string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits[class] = limit
+}
+
+// SetEnabled turns limiting on or off, see Enabled.
+func (l *Limiter) SetEnabled(enabled bool) {
+	l.disabled.Store(!enabled)
+}
+
+// Enabled reports whether requests are limited. A new limiter is enabled.
+func (l *Limiter) Enabled() bool {
+	return !l.disabled.Load()
+}
@@ -0,0 +1,88 @@
+package reload
+
+import (
+	"REST-service-sub/internal/config"
+	"REST-service-sub/internal/logger"
+	"context"
+	"github.com/rs/zerolog/log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// Reloader re-reads the configuration at runtime and hands the settings that may change
+// without a restart to the registered functions: the log level, rate limits, feature toggles
+// and CORS origins. Everything else needs a restart.
+type Reloader struct {
+	load func() (*config.Config, error)
+
+	mu    sync.Mutex
+	apply []func(*config.Config)
+}
+
+// New returns a reloader reading the configuration with load, e.g. config.Load with the
+// command line arguments. The log level is always reloaded.
+func New(load func() (*config.Config, error)) *Reloader {
+	r := &Reloader{load: load}
+	r.OnReload(func(cfg *config.Config) {
+		if err := logger.SetLevel(cfg.LogLevel); err != nil {
+			log.Error().Err(err).Msg("Failed to set log level")
+		}
+	})
+	return r
+}
+
+// OnReload registers a function applying the reloadable settings of a new configuration.
+func (r *Reloader) OnReload(apply func(*config.Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.apply = append(r.apply, apply)
+}
+
+// Reload reads and validates the configuration, then applies it. An invalid configuration is
+// reported and nothing is changed.
+func (r *Reloader) Reload() error {
+	cfg, err := r.load()
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, apply := range r.apply {
+		apply(cfg)
+	}
+	log.Info().Str("log_level", logger.Level()).Msg("Configuration reloaded")
+	return nil
+}
+
+// SetLogLevel changes the log level until the next reload.
+func (r *Reloader) SetLogLevel(level string) error {
+	if err := logger.SetLevel(level); err != nil {
+		return err
+	}
+	log.Info().Str("log_level", logger.Level()).Msg("Log level changed")
+	return nil
+}
+
+// LogLevel returns the current log level.
+func (r *Reloader) LogLevel() string {
+	return logger.Level()
+}
+
+// WatchSignals reloads on every SIGHUP until ctx is cancelled.
+func (r *Reloader) WatchSignals(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := r.Reload(); err != nil {
+				log.Error().Err(err).Msg("Failed to reload configuration, keeping the current one")
+			}
+		}
+	}
+}
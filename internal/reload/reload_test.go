@@ -0,0 +1,69 @@
+package reload
+
+import (
+	"REST-service-sub/internal/config"
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReload(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	next := &config.Config{LogLevel: "debug", RateLimitPerMinute: 120}
+	var loadErr error
+	r := New(func() (*config.Config, error) { return next, loadErr })
+	var applied []int
+	r.OnReload(func(cfg *config.Config) { applied = append(applied, cfg.RateLimitPerMinute) })
+
+	require.NoError(t, r.Reload())
+	assert.Equal(t, "debug", r.LogLevel())
+	assert.Equal(t, []int{120}, applied)
+
+	require.NoError(t, r.SetLogLevel("warn"))
+	assert.Equal(t, "warn", r.LogLevel())
+	assert.Error(t, r.SetLogLevel("verbose"))
+	assert.Equal(t, "warn", r.LogLevel(), "неизвестный уровень не меняет текущий")
+
+	// an invalid configuration changes nothing
+	loadErr = errors.New("LOG_FORMAT: \"xml\" is not one of json, console")
+	next = &config.Config{LogLevel: "error", RateLimitPerMinute: 1}
+	assert.Error(t, r.Reload())
+	assert.Equal(t, "warn", r.LogLevel())
+	assert.Equal(t, []int{120}, applied)
+}
+
+func TestWatchSignals(t *testing.T) {
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	reloaded := make(chan struct{}, 1)
+	r := New(func() (*config.Config, error) { return &config.Config{LogLevel: "info"}, nil })
+	r.OnReload(func(*config.Config) { reloaded <- struct{}{} })
+
+	// keeps an early SIGHUP from terminating the test process
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.WatchSignals(ctx)
+
+	// the handler is installed asynchronously, the signal is resent until it is seen
+	require.Eventually(t, func() bool {
+		require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP))
+		select {
+		case <-reloaded:
+			return true
+		case <-time.After(20 * time.Millisecond):
+			return false
+		}
+	}, 2*time.Second, 10*time.Millisecond)
+}
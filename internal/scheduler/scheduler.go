@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Job is a task run periodically by the Scheduler.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs jobs in-process, each in its own goroutine: once at start and then every Interval.
+// Runs of the same job never overlap; a failing or panicking run is logged and retried on the next tick.
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Start launches every job until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Wait blocks until all jobs returned after the context passed to Start was cancelled.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		s.runOnce(ctx, job)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	start := time.Now()
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return job.Run(ctx)
+	}()
+	if err != nil {
+		log.Error().Err(err).Str("job", job.Name).Msg("scheduled job failed")
+		return
+	}
+	log.Debug().Str("job", job.Name).Dur("duration", time.Since(start)).Msg("scheduled job done")
+}
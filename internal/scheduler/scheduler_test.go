@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_RunsJobsUntilCancelled(t *testing.T) {
+	var runs, failures atomic.Int32
+	s := New()
+	s.Add("count", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	s.Add("flaky", 10*time.Millisecond, func(ctx context.Context) error {
+		if failures.Add(1) == 1 {
+			panic("boom")
+		}
+		return errors.New("still failing")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Start(ctx)
+	time.Sleep(55 * time.Millisecond)
+	cancel()
+	s.Wait()
+
+	stopped := runs.Load()
+	assert.GreaterOrEqual(t, stopped, int32(3))
+	assert.GreaterOrEqual(t, failures.Load(), int32(3), "a panicking job keeps being scheduled")
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load(), "no runs after Wait returned")
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"REST-service-sub/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server is the HTTP server of the API with its timeouts and graceful shutdown.
+type Server struct {
+	srv             *http.Server
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+	beforeShutdown  []func()
+	certs           *certReloader
+	reloadInterval  time.Duration
+}
+
+// New configures the server. It serves HTTPS when TLS_CERT_FILE and TLS_KEY_FILE are set,
+// requiring client certificates signed by TLS_CLIENT_CA_FILE when it is set as well.
+func New(cfg *config.Config, handler http.Handler) (*Server, error) {
+	tlsConfig, certs, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{
+		srv: &http.Server{
+			Addr:              ":" + cfg.AppPort,
+			Handler:           handler,
+			ReadTimeout:       cfg.ServerReadTimeout,
+			ReadHeaderTimeout: cfg.ServerReadHeaderTimeout,
+			WriteTimeout:      cfg.ServerWriteTimeout,
+			IdleTimeout:       cfg.ServerIdleTimeout,
+			MaxHeaderBytes:    cfg.ServerMaxHeaderBytes,
+			TLSConfig:         tlsConfig,
+		},
+		drainDelay:      cfg.ShutdownDrainDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+		certs:           certs,
+		reloadInterval:  cfg.TLSReloadInterval,
+	}, nil
+}
+
+// BeforeShutdown registers a function called as soon as the shutdown starts, e.g. to fail the
+// readiness probe.
+func (s *Server) BeforeShutdown(f func()) {
+	s.beforeShutdown = append(s.beforeShutdown, f)
+}
+
+// OnShutdown registers a function called when the server stops accepting connections, e.g. to
+// end long-lived responses which would otherwise hold the shutdown until its deadline.
+func (s *Server) OnShutdown(f func()) {
+	s.srv.RegisterOnShutdown(f)
+}
+
+// Run listens on the configured address and serves until ctx is cancelled, see Serve.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is cancelled, then shuts down gracefully: the BeforeShutdown
+// functions run, and after the drain delay, which leaves load balancers the time to notice the
+// failing readiness, the server stops accepting connections and waits for the requests in flight
+// up to the shutdown timeout. The connections still open after it are closed.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	useTLS := s.srv.TLSConfig != nil
+	errc := make(chan error, 1)
+	go func() {
+		if useTLS {
+			errc <- s.srv.ServeTLS(ln, "", "")
+			return
+		}
+		errc <- s.srv.Serve(ln)
+	}()
+	if s.certs != nil {
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		go s.certs.watch(watchCtx, s.reloadInterval)
+	}
+	log.Info().Str("addr", ln.Addr().String()).Bool("tls", useTLS).Msg("Server is listening")
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info().Dur("drain_delay", s.drainDelay).Msg("Shutting down server")
+	for _, f := range s.beforeShutdown {
+		f()
+	}
+	time.Sleep(s.drainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.srv.Shutdown(shutdownCtx); err != nil {
+		s.srv.Close()
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Info().Msg("Server stopped")
+	return nil
+}
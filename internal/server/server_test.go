@@ -0,0 +1,148 @@
+package server
+
+import (
+	"REST-service-sub/internal/config"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		ServerReadTimeout:       time.Second,
+		ServerReadHeaderTimeout: time.Second,
+		ServerWriteTimeout:      5 * time.Second,
+		ServerIdleTimeout:       time.Second,
+		ServerMaxHeaderBytes:    1 << 20,
+		ShutdownDrainDelay:      50 * time.Millisecond,
+		ShutdownTimeout:         5 * time.Second,
+	}
+}
+
+func TestServe_CompletesInFlightRequestOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	srv, err := New(testConfig(), handler)
+	require.NoError(t, err)
+	drained := false
+	srv.BeforeShutdown(func() { drained = true })
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(addr)
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	// the server stops accepting connections while the request is still running
+	require.Eventually(t, func() bool {
+		conn, err := net.DialTimeout("tcp", ln.Addr().String(), 100*time.Millisecond)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, 2*time.Second, 10*time.Millisecond)
+
+	select {
+	case err := <-served:
+		t.Fatalf("сервер завершился до окончания запроса: %v", err)
+	default:
+	}
+
+	close(release)
+	res := <-responses
+	require.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, "done", res.body)
+
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("сервер не завершился после окончания запроса")
+	}
+	assert.True(t, drained)
+}
+
+func TestServe_ShutdownTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.ShutdownDrainDelay = 0
+	cfg.ShutdownTimeout = 50 * time.Millisecond
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	srv, err := New(cfg, handler)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("сервер не завершился по истечении таймаута")
+	}
+}
+
+func TestNew_AppliesTimeouts(t *testing.T) {
+	cfg := testConfig()
+	cfg.AppPort = "8000"
+	srv, err := New(cfg, http.NotFoundHandler())
+	require.NoError(t, err)
+
+	assert.Equal(t, ":8000", srv.srv.Addr)
+	assert.Equal(t, cfg.ServerReadTimeout, srv.srv.ReadTimeout)
+	assert.Equal(t, cfg.ServerReadHeaderTimeout, srv.srv.ReadHeaderTimeout)
+	assert.Equal(t, cfg.ServerWriteTimeout, srv.srv.WriteTimeout)
+	assert.Equal(t, cfg.ServerIdleTimeout, srv.srv.IdleTimeout)
+	assert.Equal(t, cfg.ServerMaxHeaderBytes, srv.srv.MaxHeaderBytes)
+}
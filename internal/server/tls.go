@@ -0,0 +1,179 @@
+package server
+
+import (
+	"REST-service-sub/internal/config"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"os"
+	"sync"
+	"time"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientAuthModes are the values of TLS_CLIENT_AUTH, used when a client CA is configured.
+// Certificates are always verified against the CA, only a verified one identifies the caller.
+var clientAuthModes = map[string]tls.ClientAuthType{
+	"none":            tls.NoClientCert,
+	"verify_if_given": tls.VerifyClientCertIfGiven,
+	"require":         tls.RequireAndVerifyClientCert,
+}
+
+// newTLSConfig returns the TLS settings of the server, nil when no certificate is configured.
+// The certificate and the client CA are read through a certReloader.
+func newTLSConfig(cfg *config.Config) (*tls.Config, *certReloader, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		if cfg.TLSClientCAFile != "" {
+			return nil, nil, errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil, nil
+	}
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	minVersion, ok := tlsVersions[cfg.TLSMinVersion]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown TLS_MIN_VERSION %q, expected 1.0, 1.1, 1.2 or 1.3", cfg.TLSMinVersion)
+	}
+	clientAuth := tls.NoClientCert
+	if cfg.TLSClientCAFile != "" {
+		if clientAuth, ok = clientAuthModes[cfg.TLSClientAuth]; !ok {
+			return nil, nil, fmt.Errorf("unknown TLS_CLIENT_AUTH %q, expected none, verify_if_given or require", cfg.TLSClientAuth)
+		}
+	}
+
+	reloader := &certReloader{certFile: cfg.TLSCertFile, keyFile: cfg.TLSKeyFile, caFile: cfg.TLSClientCAFile}
+	if err := reloader.load(); err != nil {
+		return nil, nil, err
+	}
+
+	base := &tls.Config{
+		MinVersion:     minVersion,
+		ClientAuth:     clientAuth,
+		GetCertificate: reloader.certificate,
+	}
+	tlsConfig := base.Clone()
+	if cfg.TLSClientCAFile != "" {
+		// every handshake gets the client CA loaded last
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c := base.Clone()
+			c.ClientCAs = reloader.clientCAs()
+			return c, nil
+		}
+	}
+	return tlsConfig, reloader, nil
+}
+
+// certReloader keeps the server certificate and the client CA pool, reloading them when their
+// files change so that renewed certificates are served without a restart.
+type certReloader struct {
+	certFile, keyFile, caFile string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime map[string]time.Time
+}
+
+func (r *certReloader) certificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) clientCAs() *x509.CertPool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	return files
+}
+
+// load reads the files. On error the certificates loaded before are kept.
+func (r *certReloader) load() error {
+	modTime := make(map[string]time.Time)
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTime[f] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load TLS certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.pool, r.modTime = &cert, pool, modTime
+	r.mu.Unlock()
+	return nil
+}
+
+// changed reports whether a file was modified since the last load.
+func (r *certReloader) changed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.files() {
+		info, err := os.Stat(f)
+		if err != nil {
+			// e.g. replaced in the middle of a rename, looked at again on the next tick
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch reloads the files every interval they changed until ctx is cancelled.
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.load(); err != nil {
+				log.Error().Err(err).Msg("Failed to reload TLS certificates, keeping the current ones")
+				continue
+			}
+			log.Info().Str("cert_file", r.certFile).Msg("TLS certificates reloaded")
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate signed by parent, self-signed when parent is nil.
+func issue(t *testing.T, cn string, parent *testCert, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"Test"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	if keyFile != "" {
+		der, err := x509.MarshalECPrivateKey(c.key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+	}
+}
+
+func (c *testCert) tls() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestServe_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "Test CA", nil, x509.ExtKeyUsageAny)
+	serverCert := issue(t, "localhost", ca, x509.ExtKeyUsageServerAuth)
+	clientCert := issue(t, "billing-service", ca, x509.ExtKeyUsageClientAuth)
+
+	cfg := testConfig()
+	cfg.TLSCertFile = filepath.Join(dir, "server.crt")
+	cfg.TLSKeyFile = filepath.Join(dir, "server.key")
+	cfg.TLSClientCAFile = filepath.Join(dir, "ca.crt")
+	cfg.TLSClientAuth = "require"
+	cfg.TLSMinVersion = "1.2"
+	cfg.TLSReloadInterval = 20 * time.Millisecond
+	serverCert.write(t, cfg.TLSCertFile, cfg.TLSKeyFile)
+	ca.write(t, cfg.TLSClientCAFile, "")
+
+	srv, err := New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.VerifiedChains[0][0].Subject.CommonName)
+	}))
+	require.NoError(t, err)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go srv.Serve(ctx, ln)
+	url := "https://" + ln.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certs},
+			DisableKeepAlives: true,
+		}}
+	}
+
+	resp, err := client(clientCert.tls()).Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "billing-service", string(body))
+
+	_, err = client().Get(url)
+	assert.Error(t, err, "без клиентского сертификата соединение должно отклоняться")
+
+	stranger := issue(t, "stranger", issue(t, "Other CA", nil, x509.ExtKeyUsageAny), x509.ExtKeyUsageClientAuth)
+	_, err = client(stranger.tls()).Get(url)
+	assert.Error(t, err, "сертификат чужого CA должен отклоняться")
+
+	// a renewed server certificate is served without a restart
+	renewed := issue(t, "localhost", ca, x509.ExtKeyUsageServerAuth)
+	renewed.write(t, cfg.TLSCertFile, cfg.TLSKeyFile)
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(cfg.TLSCertFile, future, future))
+	require.Eventually(t, func() bool {
+		resp, err := client(clientCert.tls()).Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].SerialNumber.Cmp(renewed.cert.SerialNumber) == 0
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	cert := issue(t, "localhost", nil, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	cert.write(t, certFile, keyFile)
+
+	cfg := testConfig()
+	tlsConfig, _, err := newTLSConfig(cfg)
+	assert.NoError(t, err)
+	assert.Nil(t, tlsConfig, "без сертификата сервер работает по HTTP")
+
+	cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSMinVersion = certFile, keyFile, "1.3"
+	tlsConfig, _, err = newTLSConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), tlsConfig.MinVersion)
+	assert.Equal(t, tls.NoClientCert, tlsConfig.ClientAuth)
+
+	for name, mutate := range map[string]func(){
+		"key without cert":  func() { cfg.TLSCertFile = "" },
+		"unknown version":   func() { cfg.TLSMinVersion = "1.4" },
+		"unknown auth mode": func() { cfg.TLSClientCAFile, cfg.TLSClientAuth = certFile, "sometimes" },
+		"missing file":      func() { cfg.TLSKeyFile = filepath.Join(dir, "missing.key") },
+	} {
+		cfg.TLSCertFile, cfg.TLSKeyFile, cfg.TLSClientCAFile, cfg.TLSClientAuth, cfg.TLSMinVersion = certFile, keyFile, "", "require", "1.2"
+		mutate()
+		_, _, err := newTLSConfig(cfg)
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"time"
+)
+
+type APIKeyServiceInterface interface {
+	CreateKey(context.Context, *model.APIKey) (string, error)
+	ListKeys(context.Context) ([]model.APIKey, error)
+	GetKey(context.Context, uuid.UUID) (*model.APIKey, error)
+	RevokeKey(context.Context, uuid.UUID, time.Time) error
+	ForTenant(string) APIKeyServiceInterface
+}
+
+// apiKeyTouchInterval limits how often last_used_at is written for a busy key.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService manages the API keys of one tenant, see ForTenant, and authenticates
+// requests carrying a key of any tenant.
+type APIKeyService struct {
+	db       *gorm.DB
+	root     *gorm.DB
+	tenantID string
+}
+
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+func NewAPIKeyService(db *gorm.DB) *APIKeyService {
+	return &APIKeyService{db: db, root: db}
+}
+
+// ForTenant returns the service restricted to the tenant's rows.
+func (s *APIKeyService) ForTenant(tenantID string) APIKeyServiceInterface {
+	return &APIKeyService{db: tenant.DB(s.root, tenantID), root: s.root, tenantID: tenantID}
+}
+
+// CreateKey issues a new key and returns it. The key itself is not stored and cannot be shown again.
+func (s *APIKeyService) CreateKey(ctx context.Context, k *model.APIKey) (string, error) {
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return "", err
+	}
+	k.Prefix = prefix
+	k.Hash = hash
+	if k.Scopes == nil {
+		k.Scopes = []string{}
+	}
+	k.TenantID = tenant.OrDefault(s.tenantID)
+	if err := s.db.WithContext(ctx).Create(k).Error; err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *APIKeyService) ListKeys(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	if err := s.db.WithContext(ctx).Order("created_at").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (s *APIKeyService) GetKey(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	var k model.APIKey
+	if err := s.db.WithContext(ctx).First(&k, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+// RevokeKey disables the key for good. Revoking a revoked key keeps the original revocation time.
+func (s *APIKeyService) RevokeKey(ctx context.Context, id uuid.UUID, at time.Time) error {
+	res := s.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).
+		Update("revoked_at", gorm.Expr("COALESCE(revoked_at, ?)", at))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate looks the key up in every tenant and returns the caller it stands for.
+// Unknown, revoked and expired keys yield auth.ErrInvalidAPIKey.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*auth.Principal, error) {
+	if !auth.LooksLikeAPIKey(key) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	var k model.APIKey
+	if err := s.root.WithContext(ctx).First(&k, "hash = ?", auth.HashAPIKey(key)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !k.Usable(now) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	// a lost update of last_used_at must not fail the request
+	s.root.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", k.ID, now.Add(-apiKeyTouchInterval)).
+		Update("last_used_at", now)
+
+	return &auth.Principal{
+		Subject:  "api-key:" + k.ID.String(),
+		TenantID: k.TenantID,
+		APIKeyID: &k.ID,
+		Scopes:   k.Scopes,
+	}, nil
+}
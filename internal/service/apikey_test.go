@@ -0,0 +1,51 @@
+package service
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	root := NewAPIKeyService(db)
+	acme := root.ForTenant("acme")
+
+	k := &model.APIKey{Name: "billing export", Scopes: []string{auth.PermSubscriptionsRead}}
+	key, err := acme.CreateKey(context.Background(), k)
+	require.NoError(t, err)
+	assert.True(t, auth.LooksLikeAPIKey(key))
+	assert.NotEqual(t, key, k.Hash)
+
+	p, err := root.Authenticate(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "acme", p.TenantID)
+	assert.Equal(t, k.ID, *p.APIKeyID)
+	assert.True(t, p.Can(auth.PermSubscriptionsRead))
+	assert.False(t, p.Can(auth.PermSubscriptionsWrite))
+
+	stored, err := acme.GetKey(context.Background(), k.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, err = root.ForTenant("globex").GetKey(context.Background(), k.ID)
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+
+	_, err = root.Authenticate(context.Background(), key+"x")
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	require.NoError(t, acme.RevokeKey(context.Background(), k.ID, time.Now()))
+	_, err = root.Authenticate(context.Background(), key)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	past := time.Now().Add(-time.Minute)
+	expired := &model.APIKey{Name: "expired", Scopes: []string{auth.PermAggregateRead}, ExpiresAt: &past}
+	key, err = acme.CreateKey(context.Background(), expired)
+	require.NoError(t, err)
+	_, err = root.Authenticate(context.Background(), key)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type BudgetServiceInterface interface {
+	SetBudget(context.Context, *model.Budget) error
+	ListBudgets(context.Context, uuid.UUID) ([]model.Budget, error)
+	DeleteBudget(context.Context, uuid.UUID, uuid.UUID) error
+	Status(context.Context, uuid.UUID, time.Time) ([]BudgetStatus, error)
+	ListAlerts(context.Context, uuid.UUID, int, int) ([]model.BudgetAlert, error)
+	ForTenant(string) BudgetServiceInterface
+}
+
+// BudgetStatus is the state of a budget in the month containing the evaluation time.
+// Spent is the cost of the month, Projected the cost of the next month if subscriptions stay as they are.
+type BudgetStatus struct {
+	Budget    model.Budget `json:"budget"`
+	Period    string       `json:"period" example:"2025-07"`
+	Spent     int64        `json:"spent"`
+	Remaining int64        `json:"remaining"`
+	Projected int64        `json:"projected"`
+	Exceeded  bool         `json:"exceeded"`
+}
+
+// BudgetService as returned by NewBudgetService works across tenants: every budget is evaluated
+// against the subscriptions of its own tenant. ForTenant narrows it down to one tenant.
+type BudgetService struct {
+	db       *gorm.DB
+	root     *gorm.DB
+	tenantID string
+	subs     *SubscriptionService
+}
+
+var ErrBudgetNotFound = errors.New("budget not found")
+
+func NewBudgetService(db *gorm.DB, subs *SubscriptionService) *BudgetService {
+	return &BudgetService{db: db, root: db, subs: subs}
+}
+
+// ForTenant returns the service restricted to the tenant's rows.
+func (s *BudgetService) ForTenant(tenantID string) BudgetServiceInterface {
+	return &BudgetService{db: tenant.DB(s.root, tenantID), root: s.root, tenantID: tenantID, subs: s.subs}
+}
+
+// SetBudget creates the budget or replaces the limit of the user's budget with the same scope,
+// then evaluates the user's budgets right away.
+func (s *BudgetService) SetBudget(ctx context.Context, b *model.Budget) error {
+	err := s.db.WithContext(ctx).Raw(`
+INSERT INTO budgets (tenant_id, user_id, category, service_name, monthly_limit)
+VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (tenant_id, user_id, COALESCE(category, ''), COALESCE(service_name, ''))
+DO UPDATE SET monthly_limit = EXCLUDED.monthly_limit, updated_at = NOW()
+RETURNING *
+`, tenant.OrDefault(s.tenantID), b.UserID, b.Category, b.ServiceName, b.MonthlyLimit).Scan(b).Error
+	if err != nil {
+		return err
+	}
+	return s.EvaluateUser(ctx, b.UserID, time.Now())
+}
+
+func (s *BudgetService) ListBudgets(ctx context.Context, userID uuid.UUID) ([]model.Budget, error) {
+	var budgets []model.Budget
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (s *BudgetService) DeleteBudget(ctx context.Context, userID, budgetID uuid.UUID) error {
+	tx := s.db.WithContext(ctx).Delete(&model.Budget{}, "id = ? AND user_id = ?", budgetID, userID)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// Status computes spent, remaining and projected amounts of every budget of the user.
+func (s *BudgetService) Status(ctx context.Context, userID uuid.UUID, now time.Time) ([]BudgetStatus, error) {
+	budgets, err := s.ListBudgets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for _, b := range budgets {
+		st, err := s.status(ctx, b, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses, nil
+}
+
+func (s *BudgetService) status(ctx context.Context, b model.Budget, now time.Time) (BudgetStatus, error) {
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	next := month.AddDate(0, 1, 0)
+
+	spent, err := s.monthCost(ctx, b, month)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	projected, err := s.monthCost(ctx, b, next)
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	return BudgetStatus{
+		Budget:    b,
+		Period:    month.Format("2006-01"),
+		Spent:     spent,
+		Remaining: int64(b.MonthlyLimit) - spent,
+		Projected: projected,
+		Exceeded:  spent > int64(b.MonthlyLimit),
+	}, nil
+}
+
+// monthCost is the cost of the budget's subscriptions in one month, using AggregateTotalCost arithmetic.
+func (s *BudgetService) monthCost(ctx context.Context, b model.Budget, month time.Time) (int64, error) {
+	userID := b.UserID
+	return s.subs.forTenant(b.TenantID).AggregateTotalCost(ctx, AggregateFilter{
+		PeriodStart: month,
+		PeriodEnd:   month.AddDate(0, 1, -1),
+		UserID:      &userID,
+		ServiceName: b.ServiceName,
+		Category:    b.Category,
+	})
+}
+
+// EvaluateUser records an alert for every budget of the user exceeded in the current month.
+// Alerts are unique per budget and month, so repeated evaluations are harmless.
+func (s *BudgetService) EvaluateUser(ctx context.Context, userID uuid.UUID, now time.Time) error {
+	budgets, err := s.ListBudgets(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, b := range budgets {
+		st, err := s.status(ctx, b, now)
+		if err != nil {
+			return err
+		}
+		if !st.Exceeded {
+			continue
+		}
+		alert := model.BudgetAlert{
+			BudgetID:     b.ID,
+			TenantID:     b.TenantID,
+			UserID:       b.UserID,
+			Period:       time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+			MonthlyLimit: b.MonthlyLimit,
+			Spent:        st.Spent,
+		}
+		if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&alert).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EvaluateAll evaluates the budgets of every user having one.
+func (s *BudgetService) EvaluateAll(ctx context.Context, now time.Time) error {
+	var userIDs []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&model.Budget{}).Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range userIDs {
+		if err := s.EvaluateUser(ctx, id, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListAlerts returns the user's alerts, newest first.
+func (s *BudgetService) ListAlerts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]model.BudgetAlert, error) {
+	var alerts []model.BudgetAlert
+	tx := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC")
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	if offset > 0 {
+		tx = tx.Offset(offset)
+	}
+	if err := tx.Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
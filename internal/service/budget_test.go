@@ -0,0 +1,65 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestBudgetEvaluation(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	budgets := NewBudgetService(db, subs)
+
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	endsThisMonth := month
+	userID := uuid.New()
+	require.NoError(t, subs.Create(context.Background(), &model.Subscription{
+		ID: uuid.New(), ServiceName: "Netflix", Price: 700, UserID: userID, StartDate: month.AddDate(0, -2, 0),
+	}))
+	require.NoError(t, subs.Create(context.Background(), &model.Subscription{
+		ID: uuid.New(), ServiceName: "Spotify", Price: 500, UserID: userID, StartDate: month, EndDate: &endsThisMonth,
+	}))
+
+	overall := &model.Budget{UserID: userID, MonthlyLimit: 1000}
+	require.NoError(t, budgets.SetBudget(context.Background(), overall))
+	t.Cleanup(func() { db.Where("user_id = ?", userID).Delete(&model.Budget{}) })
+	serviceName := "Netflix"
+	require.NoError(t, budgets.SetBudget(context.Background(), &model.Budget{UserID: userID, ServiceName: &serviceName, MonthlyLimit: 800}))
+
+	// same scope replaces the limit instead of adding a budget
+	require.NoError(t, budgets.SetBudget(context.Background(), &model.Budget{UserID: userID, MonthlyLimit: 1100}))
+	list, err := budgets.ListBudgets(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	statuses, err := budgets.Status(context.Background(), userID, now)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	for _, st := range statuses {
+		if st.Budget.ServiceName == nil {
+			assert.Equal(t, int64(1200), st.Spent)
+			assert.Equal(t, int64(-100), st.Remaining)
+			assert.Equal(t, int64(700), st.Projected)
+			assert.True(t, st.Exceeded)
+		} else {
+			assert.Equal(t, int64(700), st.Spent)
+			assert.False(t, st.Exceeded)
+		}
+	}
+
+	// the alert was recorded when the first limit was set, evaluating again does not duplicate it
+	require.NoError(t, budgets.EvaluateAll(context.Background(), now))
+	alerts, err := budgets.ListAlerts(context.Background(), userID, 10, 0)
+	require.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, overall.ID, alerts[0].BudgetID)
+		assert.Equal(t, int64(1200), alerts[0].Spent)
+		assert.Equal(t, 1000, alerts[0].MonthlyLimit)
+	}
+}
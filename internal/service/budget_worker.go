@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// BudgetWorker re-evaluates budgets of a user whenever one of their subscriptions changes.
+// The periodic pass over everybody (month changes, expirations) is a scheduler job calling EvaluateAll.
+type BudgetWorker struct {
+	budgets *BudgetService
+	events  *EventBroker
+}
+
+func NewBudgetWorker(budgets *BudgetService, events *EventBroker) *BudgetWorker {
+	return &BudgetWorker{budgets: budgets, events: events}
+}
+
+// Run evaluates budgets until ctx is cancelled or the event broker is closed.
+func (w *BudgetWorker) Run(ctx context.Context) {
+	changes, unsubscribe := w.events.Subscribe()
+	defer func() { unsubscribe() }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-changes:
+			if !ok {
+				if w.events.Closed() {
+					return
+				}
+				// fell behind: resubscribe and make up for the dropped events with a full pass
+				changes, unsubscribe = w.events.Subscribe()
+				if err := w.budgets.EvaluateAll(ctx, time.Now()); err != nil {
+					log.Error().Err(err).Msg("failed to evaluate budgets")
+				}
+				continue
+			}
+			if err := w.budgets.EvaluateUser(ctx, event.UserID, time.Now()); err != nil {
+				log.Error().Err(err).Str("user_id", event.UserID.String()).Msg("failed to evaluate budgets")
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type EventStreamInterface interface {
+	Subscribe() (<-chan model.SubscriptionEvent, func())
+	Since(context.Context, string, int64, *uuid.UUID, int) ([]model.SubscriptionEvent, error)
+}
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before it is dropped.
+// Dropped SSE clients reconnect with Last-Event-ID and catch up from the event log.
+const subscriberBuffer = 64
+
+// EventBroker fans subscription events out to in-process subscribers. Events reach it from any
+// replica through postgres LISTEN/NOTIFY on EventsChannel, see Listen.
+type EventBroker struct {
+	db *gorm.DB
+
+	mu          sync.Mutex
+	subscribers map[chan model.SubscriptionEvent]struct{}
+	lastID      int64
+	closed      bool
+}
+
+func NewEventBroker(db *gorm.DB) *EventBroker {
+	return &EventBroker{
+		db:          db,
+		subscribers: make(map[chan model.SubscriptionEvent]struct{}),
+	}
+}
+
+// Subscribe returns a channel of live events and a function releasing it.
+// The channel is closed when the subscriber falls too far behind or the broker is closed.
+func (b *EventBroker) Subscribe() (<-chan model.SubscriptionEvent, func()) {
+	ch := make(chan model.SubscriptionEvent, subscriberBuffer)
+	b.mu.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish hands the event to every subscriber without blocking.
+func (b *EventBroker) Publish(event model.SubscriptionEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if event.ID > b.lastID {
+		b.lastID = event.ID
+	}
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close closes the channel of every subscriber, ending the event streams on shutdown.
+// Later subscribers get a closed channel.
+func (b *EventBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Closed reports whether the broker was closed, telling a subscriber whose channel was closed
+// not to subscribe again.
+func (b *EventBroker) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Since returns up to limit events of the tenant with an id greater than lastID, oldest first,
+// optionally for one user. An empty tenantID returns the events of every tenant.
+func (b *EventBroker) Since(ctx context.Context, tenantID string, lastID int64, userID *uuid.UUID, limit int) ([]model.SubscriptionEvent, error) {
+	var events []model.SubscriptionEvent
+	tx := tenant.DB(b.db.WithContext(ctx), tenantID).Where("id > ?", lastID).Order("id")
+	if userID != nil {
+		tx = tx.Where("user_id = ?", *userID)
+	}
+	if limit > 0 {
+		tx = tx.Limit(limit)
+	}
+	if err := tx.Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Listen subscribes to EventsChannel on a dedicated connection and publishes every announced event
+// until ctx is cancelled. After a reconnect it catches up on events it may have missed.
+func (b *EventBroker) Listen(ctx context.Context, dsn string) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Warn().Err(err).Msg("event listener connection problem")
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(EventsChannel); err != nil {
+		return err
+	}
+
+	var lastID int64
+	if err := b.db.WithContext(ctx).Model(&model.SubscriptionEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&lastID).Error; err != nil {
+		return err
+	}
+	b.mu.Lock()
+	if lastID > b.lastID {
+		b.lastID = lastID
+	}
+	b.mu.Unlock()
+
+	ping := time.NewTicker(time.Minute)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-listener.Notify:
+			if n == nil {
+				b.catchUp(ctx)
+				continue
+			}
+			id, err := strconv.ParseInt(n.Extra, 10, 64)
+			if err != nil {
+				continue
+			}
+			var event model.SubscriptionEvent
+			if err := b.db.WithContext(ctx).First(&event, "id = ?", id).Error; err != nil {
+				log.Error().Err(err).Int64("event_id", id).Msg("failed to load subscription event")
+				continue
+			}
+			b.Publish(event)
+		case <-ping.C:
+			go func() { _ = listener.Ping() }()
+		}
+	}
+}
+
+func (b *EventBroker) catchUp(ctx context.Context) {
+	b.mu.Lock()
+	lastID := b.lastID
+	b.mu.Unlock()
+
+	events, err := b.Since(ctx, "", lastID, nil, 1000)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to catch up on subscription events")
+		return
+	}
+	for _, event := range events {
+		b.Publish(event)
+	}
+}
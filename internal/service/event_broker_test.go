@@ -0,0 +1,156 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func TestEventBroker_FanOut(t *testing.T) {
+	b := NewEventBroker(nil)
+	first, unsubscribeFirst := b.Subscribe()
+	second, unsubscribeSecond := b.Subscribe()
+	defer unsubscribeSecond()
+
+	b.Publish(model.SubscriptionEvent{ID: 1, Type: model.EventSubscriptionCreated})
+	assert.Equal(t, int64(1), (<-first).ID)
+	assert.Equal(t, int64(1), (<-second).ID)
+
+	unsubscribeFirst()
+	_, open := <-first
+	assert.False(t, open)
+
+	b.Publish(model.SubscriptionEvent{ID: 2, Type: model.EventSubscriptionDeleted})
+	assert.Equal(t, int64(2), (<-second).ID)
+}
+
+func TestEventBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewEventBroker(nil)
+	slow, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	for i := 1; i <= subscriberBuffer+1; i++ {
+		b.Publish(model.SubscriptionEvent{ID: int64(i)})
+	}
+
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+}
+
+func TestEventBroker_Close(t *testing.T) {
+	b := NewEventBroker(nil)
+	live, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	b.Close()
+	_, open := <-live
+	assert.False(t, open)
+
+	late, unsubscribeLate := b.Subscribe()
+	defer unsubscribeLate()
+	_, open = <-late
+	assert.False(t, open, "подписка после закрытия должна сразу завершаться")
+	assert.True(t, b.Closed())
+	b.Publish(model.SubscriptionEvent{ID: 1})
+}
+
+func TestBudgetWorker_StopsWhenBrokerClosed(t *testing.T) {
+	b := NewEventBroker(nil)
+	b.Close()
+
+	done := make(chan struct{})
+	go func() {
+		NewBudgetWorker(nil, b).Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("воркер бюджетов не остановился после закрытия брокера")
+	}
+}
+
+func TestEventBroker_SinceReadsEventLog(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+	broker := NewEventBroker(db)
+
+	sub := &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Yandex Plus",
+		Price:       400,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, svc.Create(context.Background(), sub))
+	sub.Price = 500
+	assert.NoError(t, svc.Update(context.Background(), sub.ID, sub))
+
+	events, err := broker.Since(context.Background(), tenant.Default, 0, &sub.UserID, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, model.EventSubscriptionCreated, events[0].Type)
+		assert.Equal(t, model.EventSubscriptionUpdated, events[1].Type)
+		assert.Equal(t, sub.ID, events[1].SubscriptionID)
+	}
+
+	rest, err := broker.Since(context.Background(), tenant.Default, events[0].ID, &sub.UserID, 10)
+	assert.NoError(t, err)
+	assert.Len(t, rest, 1)
+}
+
+func TestRecordEvent_IDsFollowCommitOrder(t *testing.T) {
+	db := setupTestDB(t)
+	broker := NewEventBroker(db)
+	userID := uuid.New()
+	newSub := func() *model.Subscription {
+		return &model.Subscription{
+			ID:          uuid.New(),
+			TenantID:    tenant.Default,
+			ServiceName: "Kinopoisk",
+			Price:       300,
+			UserID:      userID,
+			StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	first, second := newSub(), newSub()
+
+	txA := db.Begin()
+	require.NoError(t, txA.Error)
+	require.NoError(t, recordEvent(txA, model.EventSubscriptionCreated, first))
+
+	// B records its event after A and tries to commit first
+	done := make(chan error, 1)
+	go func() {
+		done <- db.Transaction(func(tx *gorm.DB) error {
+			return recordEvent(tx, model.EventSubscriptionCreated, second)
+		})
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("событие B зафиксировано раньше события A: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	events, err := broker.Since(context.Background(), tenant.Default, 0, &userID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	require.NoError(t, txA.Commit().Error)
+	require.NoError(t, <-done)
+
+	events, err = broker.Since(context.Background(), tenant.Default, 0, &userID, 10)
+	require.NoError(t, err)
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, first.ID, events[0].SubscriptionID)
+		assert.Equal(t, second.ID, events[1].SubscriptionID)
+	}
+}
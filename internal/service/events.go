@@ -0,0 +1,93 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strconv"
+	"time"
+)
+
+// EventsChannel is the postgres LISTEN/NOTIFY channel announcing new subscription events.
+const EventsChannel = "subscription_events"
+
+// eventLogLock is the transaction-level advisory lock serializing event ids with commits.
+const eventLogLock = 0x7375625f65767473
+
+// recordEvent appends a lifecycle event to the change log, queues a webhook delivery for every
+// active endpoint subscribed to its type and notifies listeners on EventsChannel.
+// It must run inside the transaction that made the change: the notification is sent on commit.
+//
+// Readers use the event id as a cursor, so ids must become visible in order. The id is taken
+// under an advisory lock held until commit: a later event cannot commit before an earlier one.
+// It must be the last write of the transaction, the lock serializes event writers until commit.
+func recordEvent(tx *gorm.DB, eventType string, sub *model.Subscription) error {
+	payload, err := json.Marshal(sub)
+	if err != nil {
+		return err
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", int64(eventLogLock)).Error; err != nil {
+		return err
+	}
+	event := model.SubscriptionEvent{
+		Type:           eventType,
+		TenantID:       sub.TenantID,
+		SubscriptionID: sub.ID,
+		UserID:         sub.UserID,
+		Payload:        payload,
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		// already recorded, e.g. an expiration noticed by another replica
+		return nil
+	}
+
+	err = tx.Exec(`
+INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, tenant_id)
+SELECT id, ?, ?, tenant_id FROM webhook_endpoints
+WHERE tenant_id = ? AND active AND (cardinality(event_types) = 0 OR ? = ANY(event_types))
+`, event.ID, event.Type, event.TenantID, event.Type).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec("SELECT pg_notify(?, ?)", EventsChannel, strconv.FormatInt(event.ID, 10)).Error
+}
+
+// RecordExpirations emits a subscription.expired event for every subscription whose last paid
+// month (end_date) ended before now. It returns the number of subscriptions checked.
+func (s *SubscriptionService) RecordExpirations(ctx context.Context, now time.Time) (int, error) {
+	var expired []model.Subscription
+	err := s.db.WithContext(ctx).Where(`end_date IS NOT NULL AND end_date + INTERVAL '1 month' <= ?`, now).
+		Where(`NOT EXISTS (SELECT 1 FROM subscription_events e WHERE e.subscription_id = subscriptions.id AND e.type = ?)`, model.EventSubscriptionExpired).
+		Find(&expired).Error
+	if err != nil {
+		return 0, err
+	}
+	for i := range expired {
+		if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return recordEvent(tx, model.EventSubscriptionExpired, &expired[i])
+		}); err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
+
+// History returns the change log of a subscription, oldest first. It is kept after the
+// subscription is deleted.
+func (s *SubscriptionService) History(ctx context.Context, id uuid.UUID) ([]model.SubscriptionEvent, error) {
+	var events []model.SubscriptionEvent
+	if err := s.db.WithContext(ctx).Where("subscription_id = ?", id).Order("id").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrSubscriptionNotFound
+	}
+	return events, nil
+}
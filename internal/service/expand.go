@@ -0,0 +1,75 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+)
+
+const (
+	ExpandUser    = "user"
+	ExpandService = "service"
+)
+
+// Expansions holds the related resources of a page of subscriptions, keyed the way subscriptions reference them.
+type Expansions struct {
+	Users    map[uuid.UUID]model.User
+	Services map[string]model.Service
+}
+
+// SubscriptionColumns maps JSON field names of model.Subscription to their columns.
+func SubscriptionColumns(fields []string) ([]string, error) {
+	cols := make([]string, 0, len(fields))
+	for _, f := range fields {
+		field, ok := SubscriptionFilterSchema[f]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", f)
+		}
+		cols = append(cols, field.Column)
+	}
+	return cols, nil
+}
+
+// Expand loads the requested relations (ExpandUser, ExpandService) of subs in one query per relation.
+// Relations without a matching row are simply absent from the result.
+func (s *SubscriptionService) Expand(ctx context.Context, subs []model.Subscription, relations []string) (*Expansions, error) {
+	exp := &Expansions{}
+	for _, rel := range relations {
+		switch rel {
+		case ExpandUser:
+			ids := make([]uuid.UUID, 0, len(subs))
+			for _, sub := range subs {
+				ids = append(ids, sub.UserID)
+			}
+			var users []model.User
+			if len(ids) > 0 {
+				if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error; err != nil {
+					return nil, err
+				}
+			}
+			exp.Users = make(map[uuid.UUID]model.User, len(users))
+			for _, u := range users {
+				exp.Users[u.ID] = u
+			}
+		case ExpandService:
+			names := make([]string, 0, len(subs))
+			for _, sub := range subs {
+				names = append(names, sub.ServiceName)
+			}
+			var services []model.Service
+			if len(names) > 0 {
+				if err := s.db.WithContext(ctx).Where("name IN ?", names).Find(&services).Error; err != nil {
+					return nil, err
+				}
+			}
+			exp.Services = make(map[string]model.Service, len(services))
+			for _, svc := range services {
+				exp.Services[svc.Name] = svc
+			}
+		default:
+			return nil, fmt.Errorf("unknown relation %q", rel)
+		}
+	}
+	return exp, nil
+}
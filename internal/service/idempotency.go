@@ -0,0 +1,75 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// IdempotencyService stores the responses of requests made with an Idempotency-Key header,
+// see middleware.Idempotency.
+type IdempotencyService struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{db: db}
+}
+
+// Begin claims the key for rec. It returns nil when the key was free, or expired, and the
+// request may proceed, otherwise the record of the earlier request with the key.
+func (s *IdempotencyService) Begin(ctx context.Context, rec *model.IdempotencyKey, now time.Time) (*model.IdempotencyKey, error) {
+	var existing *model.IdempotencyKey
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		pk := tx.Where("tenant_id = ? AND client = ? AND key = ?", rec.TenantID, rec.Client, rec.Key)
+		if err := pk.Where("expires_at <= ?", now).Delete(&model.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(rec)
+		if res.Error != nil || res.RowsAffected == 1 {
+			return res.Error
+		}
+
+		var found model.IdempotencyKey
+		err := tx.Where("tenant_id = ? AND client = ? AND key = ?", rec.TenantID, rec.Client, rec.Key).First(&found).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// released by the other request in the meantime
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(rec).Error
+		}
+		if err != nil {
+			return err
+		}
+		existing = &found
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// Complete stores the response of the request that claimed the key.
+func (s *IdempotencyService) Complete(ctx context.Context, rec *model.IdempotencyKey) error {
+	return s.db.WithContext(ctx).Model(&model.IdempotencyKey{}).
+		Where("tenant_id = ? AND client = ? AND key = ?", rec.TenantID, rec.Client, rec.Key).
+		Updates(map[string]interface{}{
+			"status_code":  rec.StatusCode,
+			"content_type": rec.ContentType,
+			"response":     rec.Response,
+		}).Error
+}
+
+// Release frees the key, so that the request can be retried with it.
+func (s *IdempotencyService) Release(ctx context.Context, rec *model.IdempotencyKey) error {
+	return s.db.WithContext(ctx).Where("tenant_id = ? AND client = ? AND key = ?", rec.TenantID, rec.Client, rec.Key).
+		Delete(&model.IdempotencyKey{}).Error
+}
+
+// Purge deletes the keys expired at now.
+func (s *IdempotencyService) Purge(ctx context.Context, now time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&model.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
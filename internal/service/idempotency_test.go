@@ -0,0 +1,65 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewIdempotencyService(db)
+	now := time.Now()
+
+	newRec := func(fingerprint string, at time.Time) *model.IdempotencyKey {
+		return &model.IdempotencyKey{
+			TenantID:    "acme",
+			Client:      "user:acme:" + uuid.Nil.String(),
+			Key:         "key-" + t.Name(),
+			Fingerprint: fingerprint,
+			ExpiresAt:   at.Add(time.Hour),
+		}
+	}
+	require.NoError(t, svc.Release(context.Background(), newRec("", now)))
+
+	rec := newRec("a", now)
+	existing, err := svc.Begin(context.Background(), rec, now)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// in progress
+	existing, err = svc.Begin(context.Background(), newRec("a", now), now)
+	require.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Nil(t, existing.StatusCode)
+	}
+
+	status := http.StatusCreated
+	rec.StatusCode = &status
+	rec.ContentType = "application/json"
+	rec.Response = []byte(`{"id":1}`)
+	require.NoError(t, svc.Complete(context.Background(), rec))
+
+	existing, err = svc.Begin(context.Background(), newRec("b", now), now)
+	require.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, "a", existing.Fingerprint)
+		assert.Equal(t, http.StatusCreated, *existing.StatusCode)
+		assert.Equal(t, `{"id":1}`, string(existing.Response))
+	}
+
+	// an expired key may be used again
+	later := now.Add(2 * time.Hour)
+	existing, err = svc.Begin(context.Background(), newRec("b", later), later)
+	require.NoError(t, err)
+	assert.Nil(t, existing)
+
+	purged, err := svc.Purge(context.Background(), later.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, purged, int64(1))
+}
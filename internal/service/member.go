@@ -0,0 +1,178 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MemberServiceInterface interface {
+	SetMembers(context.Context, uuid.UUID, []model.SubscriptionMember) ([]model.SubscriptionMember, error)
+	ListMembers(context.Context, uuid.UUID) ([]model.SubscriptionMember, error)
+	ListShared(context.Context, uuid.UUID) ([]SharedSubscription, error)
+	ForTenant(string) MemberServiceInterface
+}
+
+// SharedSubscription is a subscription with members, seen by one of its participants.
+// Share is the monthly amount attributed to that participant.
+type SharedSubscription struct {
+	Subscription model.Subscription         `json:"subscription"`
+	Members      []model.SubscriptionMember `json:"members"`
+	Payer        bool                       `json:"payer"`
+	Share        int                        `json:"share"`
+}
+
+type MemberService struct {
+	db   *gorm.DB
+	root *gorm.DB
+}
+
+// ErrInvalidMembers is returned when a member list cannot be applied to the subscription.
+var ErrInvalidMembers = errors.New("invalid members")
+
+func NewMemberService(db *gorm.DB) *MemberService {
+	return &MemberService{db: db, root: db}
+}
+
+// ForTenant returns the service restricted to the tenant's rows.
+func (s *MemberService) ForTenant(tenantID string) MemberServiceInterface {
+	return &MemberService{db: tenant.DB(s.root, tenantID), root: s.root}
+}
+
+// SetMembers replaces the members of a subscription. Fixed amounts may not exceed the price.
+func (s *MemberService) SetMembers(ctx context.Context, subID uuid.UUID, members []model.SubscriptionMember) ([]model.SubscriptionMember, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sub model.Subscription
+		err := tx.First(&sub, "id = ?", subID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSubscriptionNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[uuid.UUID]bool, len(members))
+		fixed := 0
+		for i := range members {
+			m := &members[i]
+			if seen[m.UserID] {
+				return fmt.Errorf("%w: user %s is listed twice", ErrInvalidMembers, m.UserID)
+			}
+			seen[m.UserID] = true
+			if (m.Weight == nil) == (m.Amount == nil) {
+				return fmt.Errorf("%w: member %s needs either weight or amount", ErrInvalidMembers, m.UserID)
+			}
+			if m.Amount != nil {
+				fixed += *m.Amount
+			}
+			m.SubscriptionID = subID
+			m.TenantID = sub.TenantID
+		}
+		if fixed > sub.Price {
+			return fmt.Errorf("%w: fixed amounts %d exceed the price %d", ErrInvalidMembers, fixed, sub.Price)
+		}
+
+		if err := tx.Delete(&model.SubscriptionMember{}, "subscription_id = ?", subID).Error; err != nil {
+			return err
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		return tx.Create(&members).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (s *MemberService) ListMembers(ctx context.Context, subID uuid.UUID) ([]model.SubscriptionMember, error) {
+	var sub model.Subscription
+	err := s.db.WithContext(ctx).Select("id").First(&sub, "id = ?", subID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var members []model.SubscriptionMember
+	if err := s.db.WithContext(ctx).Where("subscription_id = ?", subID).Order("created_at, user_id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListShared returns the subscriptions with members that the user pays for or is a member of.
+func (s *MemberService) ListShared(ctx context.Context, userID uuid.UUID) ([]SharedSubscription, error) {
+	var subs []model.Subscription
+	err := s.db.WithContext(ctx).
+		Where("EXISTS (SELECT 1 FROM subscription_members sm WHERE sm.subscription_id = subscriptions.id)").
+		Where("user_id = ? OR id IN (SELECT subscription_id FROM subscription_members WHERE user_id = ?)", userID, userID).
+		Order("start_date, id").
+		Find(&subs).Error
+	if err != nil || len(subs) == 0 {
+		return []SharedSubscription{}, err
+	}
+
+	ids := make([]uuid.UUID, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ID
+	}
+	var members []model.SubscriptionMember
+	if err := s.db.WithContext(ctx).Where("subscription_id IN ?", ids).Order("created_at, user_id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	bySub := make(map[uuid.UUID][]model.SubscriptionMember)
+	for _, m := range members {
+		bySub[m.SubscriptionID] = append(bySub[m.SubscriptionID], m)
+	}
+
+	shared := make([]SharedSubscription, len(subs))
+	for i, sub := range subs {
+		shared[i] = SharedSubscription{
+			Subscription: sub,
+			Members:      bySub[sub.ID],
+			Payer:        sub.UserID == userID,
+			Share:        MemberShares(sub, bySub[sub.ID])[userID],
+		}
+	}
+	return shared, nil
+}
+
+// MemberShares splits the monthly price between the payer and the members: fixed amounts first,
+// then what is left by weight, rounded down. The payer gets whatever the members do not cover,
+// so the shares always add up to the price. It mirrors the share attribution of AggregateTotalCost.
+func MemberShares(sub model.Subscription, members []model.SubscriptionMember) map[uuid.UUID]int {
+	fixed, weights := 0, 0
+	for _, m := range members {
+		if m.Amount != nil {
+			fixed += *m.Amount
+		} else if m.Weight != nil {
+			weights += *m.Weight
+		}
+	}
+	rest := max(sub.Price-fixed, 0)
+
+	shares := make(map[uuid.UUID]int, len(members)+1)
+	others := 0
+	for _, m := range members {
+		share := 0
+		switch {
+		case m.Amount != nil:
+			share = *m.Amount
+		case m.Weight != nil && weights > 0:
+			share = rest * *m.Weight / weights
+		}
+		shares[m.UserID] = share
+		if m.UserID != sub.UserID {
+			others += share
+		}
+	}
+	shares[sub.UserID] = sub.Price - others
+	return shares
+}
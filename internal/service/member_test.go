@@ -0,0 +1,82 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestMemberShares(t *testing.T) {
+	payer, a, b, c := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	sub := model.Subscription{Price: 1000, UserID: payer}
+
+	shares := MemberShares(sub, []model.SubscriptionMember{
+		{UserID: a, Amount: intPtr(100)},
+		{UserID: b, Weight: intPtr(1)},
+		{UserID: c, Weight: intPtr(2)},
+	})
+	// 900 left after the fixed amount: 300 and 600, nothing for the payer
+	assert.Equal(t, map[uuid.UUID]int{payer: 0, a: 100, b: 300, c: 600}, shares)
+
+	// the payer listed with a weight also takes the rounding remainder
+	shares = MemberShares(sub, []model.SubscriptionMember{
+		{UserID: payer, Weight: intPtr(1)},
+		{UserID: a, Weight: intPtr(1)},
+		{UserID: b, Weight: intPtr(1)},
+	})
+	assert.Equal(t, map[uuid.UUID]int{payer: 334, a: 333, b: 333}, shares)
+
+	assert.Equal(t, map[uuid.UUID]int{payer: 1000}, MemberShares(sub, nil))
+}
+
+func TestSharedSubscriptionAttribution(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	members := NewMemberService(db)
+
+	month := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	payer, member := uuid.New(), uuid.New()
+	family := &model.Subscription{ID: uuid.New(), ServiceName: "Spotify Family", Price: 500, UserID: payer, StartDate: month}
+	personal := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 700, UserID: payer, StartDate: month}
+	require.NoError(t, subs.Create(context.Background(), family))
+	require.NoError(t, subs.Create(context.Background(), personal))
+
+	_, err := members.SetMembers(context.Background(), family.ID, []model.SubscriptionMember{
+		{UserID: payer, Weight: intPtr(1)},
+		{UserID: member, Weight: intPtr(4)},
+	})
+	require.NoError(t, err)
+	_, err = members.SetMembers(context.Background(), family.ID, []model.SubscriptionMember{{UserID: member, Amount: intPtr(600)}})
+	assert.ErrorIs(t, err, ErrInvalidMembers)
+	_, err = members.SetMembers(context.Background(), uuid.New(), nil)
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+	total := func(userID uuid.UUID, attribution string) int64 {
+		cost, err := subs.AggregateTotalCost(context.Background(), AggregateFilter{
+			PeriodStart: month, PeriodEnd: month.AddDate(0, 1, 0), UserID: &userID, Attribution: attribution,
+		})
+		require.NoError(t, err)
+		return cost
+	}
+	assert.Equal(t, int64(2400), total(payer, AttributionPayer))
+	assert.Equal(t, int64(0), total(member, AttributionPayer))
+	assert.Equal(t, int64(1600), total(payer, AttributionShare))
+	assert.Equal(t, int64(800), total(member, AttributionShare))
+
+	shared, err := members.ListShared(context.Background(), member)
+	require.NoError(t, err)
+	if assert.Len(t, shared, 1) {
+		assert.Equal(t, family.ID, shared[0].Subscription.ID)
+		assert.False(t, shared[0].Payer)
+		assert.Equal(t, 400, shared[0].Share)
+		assert.Len(t, shared[0].Members, 2)
+	}
+}
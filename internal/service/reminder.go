@@ -0,0 +1,169 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/notify"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ReminderService notifies users DaysBefore days ahead of a subscription's end_date and of its next monthly charge.
+type ReminderService struct {
+	db         *gorm.DB
+	notifier   notify.Notifier
+	daysBefore int
+}
+
+func NewReminderService(db *gorm.DB, notifier notify.Notifier, daysBefore int) *ReminderService {
+	return &ReminderService{db: db, notifier: notifier, daysBefore: daysBefore}
+}
+
+// SendDue sends every reminder due within the window and returns how many were sent.
+// Paused subscriptions are not charged and get no reminders until they are resumed.
+// end_date is the last charged month, so a subscription ending this month still gets reminders.
+// A reminder is marked as sent before notifying, so it goes out at most once even across replicas;
+// the mark is removed when the notifier fails, so the next run retries it.
+func (s *ReminderService) SendDue(ctx context.Context, now time.Time) (int, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := today.AddDate(0, 0, s.daysBefore)
+	monthStart := today.AddDate(0, 0, 1-today.Day())
+
+	var subs []model.Subscription
+	err := s.db.WithContext(ctx).
+		Where("status <> ? AND start_date <= ? AND (end_date IS NULL OR end_date >= ?)", model.StatusPaused, horizon, monthStart).
+		Find(&subs).Error
+	if err != nil {
+		return 0, err
+	}
+
+	emails := make(map[userKey]string)
+	sent := 0
+	for _, sub := range subs {
+		var due []notify.Reminder
+		if sub.EndDate != nil && !sub.EndDate.After(horizon) {
+			due = append(due, notify.Reminder{Kind: notify.ReminderEndDate, DueDate: *sub.EndDate})
+		}
+		if next, ok := nextCharge(sub, today); ok && !next.After(horizon) {
+			due = append(due, notify.Reminder{Kind: notify.ReminderNextCharge, DueDate: next})
+		}
+
+		for _, r := range due {
+			if ctx.Err() != nil {
+				return sent, ctx.Err()
+			}
+			email, err := s.email(ctx, emails, userKey{tenantID: sub.TenantID, userID: sub.UserID})
+			if err != nil {
+				return sent, err
+			}
+			r.SubscriptionID = sub.ID
+			r.TenantID = sub.TenantID
+			r.UserID = sub.UserID
+			r.Email = email
+			r.ServiceName = sub.ServiceName
+			r.Price = sub.Price
+
+			ok, err := s.send(ctx, r)
+			if err != nil {
+				log.Error().Err(err).
+					Str("subscription_id", sub.ID.String()).
+					Str("kind", r.Kind).
+					Msg("failed to send reminder")
+				continue
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+func (s *ReminderService) send(ctx context.Context, r notify.Reminder) (bool, error) {
+	mark := model.ReminderSent{
+		SubscriptionID: r.SubscriptionID,
+		TenantID:       r.TenantID,
+		Kind:           r.Kind,
+		DueDate:        r.DueDate,
+		UserID:         r.UserID,
+	}
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&mark)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	err := s.notifier.Notify(ctx, r)
+	if errors.Is(err, notify.ErrNoRecipient) {
+		// retrying does not help until the user has a contact, keep the mark
+		log.Warn().Str("user_id", r.UserID.String()).Msg("reminder skipped: user has no contact")
+		return false, nil
+	}
+	if err != nil {
+		// unmarked even when ctx was cancelled, or the reminder would never be retried
+		if delErr := s.db.WithContext(context.WithoutCancel(ctx)).Delete(&mark).Error; delErr != nil {
+			log.Error().Err(delErr).Msg("failed to unmark unsent reminder")
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// userKey identifies a user: user ids are only unique within a tenant.
+type userKey struct {
+	tenantID string
+	userID   uuid.UUID
+}
+
+func (s *ReminderService) email(ctx context.Context, cache map[userKey]string, key userKey) (string, error) {
+	if email, ok := cache[key]; ok {
+		return email, nil
+	}
+	var user model.User
+	err := s.db.WithContext(ctx).Select("email").First(&user, "id = ? AND tenant_id = ?", key.userID, key.tenantID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+	email := ""
+	if user.Email != nil {
+		email = *user.Email
+	}
+	cache[key] = email
+	return email, nil
+}
+
+// nextCharge returns the first monthly charge on or after today. Charges happen on the day of month
+// of start_date (clamped to shorter months), from start_date up to the month of end_date.
+func nextCharge(sub model.Subscription, today time.Time) (time.Time, bool) {
+	start := time.Date(sub.StartDate.Year(), sub.StartDate.Month(), sub.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	if !start.Before(today) {
+		return start, true
+	}
+
+	charge := chargeDay(today.Year(), today.Month(), start.Day())
+	if charge.Before(today) {
+		next := today.AddDate(0, 0, 1-today.Day()).AddDate(0, 1, 0)
+		charge = chargeDay(next.Year(), next.Month(), start.Day())
+	}
+	if sub.EndDate != nil {
+		lastMonth := time.Date(sub.EndDate.Year(), sub.EndDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if !charge.Before(lastMonth.AddDate(0, 1, 0)) {
+			return time.Time{}, false
+		}
+	}
+	return charge, true
+}
+
+func chargeDay(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
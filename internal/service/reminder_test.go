@@ -0,0 +1,146 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/notify"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	sent []notify.Reminder
+	err  error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, r notify.Reminder) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, r)
+	return nil
+}
+
+func TestNextCharge(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	end := day(2025, 3, 1)
+
+	tests := []struct {
+		name  string
+		sub   model.Subscription
+		today time.Time
+		want  time.Time
+		ok    bool
+	}{
+		{"starts in future", model.Subscription{StartDate: day(2025, 5, 10)}, day(2025, 5, 1), day(2025, 5, 10), true},
+		{"later this month", model.Subscription{StartDate: day(2025, 1, 20)}, day(2025, 4, 15), day(2025, 4, 20), true},
+		{"next month", model.Subscription{StartDate: day(2025, 1, 5)}, day(2025, 4, 15), day(2025, 5, 5), true},
+		{"clamped to short month", model.Subscription{StartDate: day(2025, 1, 31)}, day(2025, 2, 1), day(2025, 2, 28), true},
+		{"last month charged", model.Subscription{StartDate: day(2025, 1, 5), EndDate: &end}, day(2025, 3, 1), day(2025, 3, 5), true},
+		{"after end", model.Subscription{StartDate: day(2025, 1, 5), EndDate: &end}, day(2025, 3, 10), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := nextCharge(tt.sub, tt.today)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSendDueReminders(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+
+	now := time.Date(2025, 6, 28, 10, 0, 0, 0, time.UTC)
+	email := "reminders@example.com"
+	user := model.User{ID: uuid.New(), TenantID: tenant.Default, Name: "reminders", Email: &email}
+	require.NoError(t, db.Create(&user).Error)
+	t.Cleanup(func() {
+		db.Where("user_id = ?", user.ID).Delete(&model.ReminderSent{})
+		db.Delete(&user)
+	})
+
+	end := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	ending := &model.Subscription{
+		ID: uuid.New(), ServiceName: "Netflix", Price: 700, UserID: user.ID,
+		StartDate: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), EndDate: &end,
+	}
+	charged := &model.Subscription{
+		ID: uuid.New(), ServiceName: "Spotify", Price: 300, UserID: user.ID,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, subs.Create(context.Background(), ending))
+	require.NoError(t, subs.Create(context.Background(), charged))
+	paused := &model.Subscription{
+		ID: uuid.New(), ServiceName: "Okko", Price: 400, UserID: user.ID,
+		StartDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, subs.Create(context.Background(), paused))
+	_, err := subs.Pause(context.Background(), paused.ID, now)
+	require.NoError(t, err)
+
+	failing := &recordingNotifier{err: errors.New("smtp unavailable")}
+	n, err := NewReminderService(db, failing, 3).SendDue(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// failed reminders are retried on the next run, sent ones are not repeated
+	notifier := &recordingNotifier{}
+	reminders := NewReminderService(db, notifier, 3)
+	n, err = reminders.SendDue(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	n, err = reminders.SendDue(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	require.Len(t, notifier.sent, 2)
+	kinds := map[string]notify.Reminder{}
+	for _, r := range notifier.sent {
+		assert.Equal(t, email, r.Email)
+		assert.NotEqual(t, paused.ID, r.SubscriptionID, "приостановленная подписка не списывается, напоминаний нет")
+		kinds[r.Kind] = r
+	}
+	assert.Equal(t, ending.ID, kinds[notify.ReminderEndDate].SubscriptionID)
+	assert.Equal(t, charged.ID, kinds[notify.ReminderNextCharge].SubscriptionID)
+	assert.True(t, kinds[notify.ReminderNextCharge].DueDate.Equal(time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestSendDueReminders_LastMonth(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+
+	email := "last-month@example.com"
+	user := model.User{ID: uuid.New(), TenantID: tenant.Default, Name: "last-month", Email: &email}
+	require.NoError(t, db.Create(&user).Error)
+	t.Cleanup(func() {
+		db.Where("user_id = ?", user.ID).Delete(&model.ReminderSent{})
+		db.Delete(&user)
+	})
+
+	// end_date is month-granular: June is the last charged month
+	end := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ending := &model.Subscription{
+		ID: uuid.New(), ServiceName: "Kion", Price: 250, UserID: user.ID,
+		StartDate: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), EndDate: &end,
+	}
+	require.NoError(t, subs.Create(context.Background(), ending))
+
+	notifier := &recordingNotifier{}
+	n, err := NewReminderService(db, notifier, 7).SendDue(context.Background(), time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, "подписка в последнем оплаченном месяце должна получить напоминания")
+
+	kinds := map[string]notify.Reminder{}
+	for _, r := range notifier.sent {
+		kinds[r.Kind] = r
+	}
+	assert.True(t, kinds[notify.ReminderNextCharge].DueDate.Equal(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, ending.ID, kinds[notify.ReminderEndDate].SubscriptionID)
+}
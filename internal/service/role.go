@@ -0,0 +1,85 @@
+package service
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RoleServiceInterface interface {
+	ListRoles(context.Context, uuid.UUID) ([]string, error)
+	SetRoles(context.Context, uuid.UUID, []string) ([]string, error)
+	ForTenant(string) RoleServiceInterface
+}
+
+var ErrUnknownRole = errors.New("unknown role")
+
+// RoleService manages the role assignments of one tenant, see ForTenant.
+type RoleService struct {
+	db       *gorm.DB
+	root     *gorm.DB
+	tenantID string
+}
+
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db, root: db}
+}
+
+// ForTenant returns the service restricted to the tenant's rows.
+func (s *RoleService) ForTenant(tenantID string) RoleServiceInterface {
+	return &RoleService{db: tenant.DB(s.root, tenantID), root: s.root, tenantID: tenantID}
+}
+
+// ListRoles returns the roles assigned to the user, without the roles carried by its tokens.
+func (s *RoleService) ListRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	roles := []string{}
+	err := s.db.WithContext(ctx).Model(&model.RoleAssignment{}).Where("user_id = ?", userID).Order("role").Pluck("role", &roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// SetRoles replaces the roles assigned to the user. An empty list leaves the user with auth.DefaultRole.
+func (s *RoleService) SetRoles(ctx context.Context, userID uuid.UUID, roles []string) ([]string, error) {
+	assignments := make([]model.RoleAssignment, 0, len(roles))
+	seen := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		if !auth.ValidRole(role) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownRole, role)
+		}
+		if seen[role] {
+			continue
+		}
+		seen[role] = true
+		assignments = append(assignments, model.RoleAssignment{
+			TenantID: tenant.OrDefault(s.tenantID),
+			UserID:   userID,
+			Role:     role,
+		})
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.RoleAssignment{}, "user_id = ?", userID).Error; err != nil {
+			return err
+		}
+		if len(assignments) == 0 {
+			return nil
+		}
+		return tx.Create(&assignments).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.ListRoles(ctx, userID)
+}
+
+// RolesOf returns the roles assigned to the user in the tenant.
+func (s *RoleService) RolesOf(ctx context.Context, tenantID string, userID uuid.UUID) ([]string, error) {
+	return s.ForTenant(tenantID).ListRoles(ctx, userID)
+}
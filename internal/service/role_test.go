@@ -0,0 +1,63 @@
+package service
+
+import (
+	"REST-service-sub/internal/auth"
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRoleAssignments(t *testing.T) {
+	db := setupTestDB(t)
+	roles := NewRoleService(db)
+	userID := uuid.New()
+
+	assigned, err := roles.ForTenant("acme").SetRoles(context.Background(), userID, []string{auth.RoleFinance, auth.RoleAuditor, auth.RoleFinance})
+	require.NoError(t, err)
+	assert.Equal(t, []string{auth.RoleAuditor, auth.RoleFinance}, assigned)
+
+	other, err := roles.RolesOf(context.Background(), "globex", userID)
+	require.NoError(t, err)
+	assert.Empty(t, other)
+
+	_, err = roles.ForTenant("acme").SetRoles(context.Background(), userID, []string{"superuser"})
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	assigned, err = roles.ForTenant("acme").SetRoles(context.Background(), userID, nil)
+	require.NoError(t, err)
+	assert.Empty(t, assigned)
+}
+
+func TestSubscriptionHistory(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+
+	sub := &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       700,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, svc.Create(context.Background(), sub))
+	_, err := svc.Cancel(context.Background(), sub.ID, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(context.Background(), sub.ID))
+
+	events, err := svc.History(context.Background(), sub.ID)
+	require.NoError(t, err)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{model.EventSubscriptionCreated, model.EventSubscriptionCancelled, model.EventSubscriptionDeleted}, types)
+
+	_, err = svc.ForTenant("acme").History(context.Background(), sub.ID)
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	_, err = svc.History(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+}
@@ -1,112 +1,363 @@
 package service
 
 import (
+	"REST-service-sub/internal/filter"
+	"REST-service-sub/internal/metrics"
 	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+	"sync"
 	"time"
 )
 
 type SubscriptionServiceInterface interface {
-	Create(*model.Subscription) error
-	GetByID(uuid.UUID) (*model.Subscription, error)
-	Update(uuid.UUID, *model.Subscription) error
-	Delete(uuid.UUID) error
-	List(map[string]interface{}, int, int) ([]model.Subscription, error)
-	AggregateTotalCost(time.Time, time.Time, *uuid.UUID, *string) (int64, error)
+	Create(context.Context, *model.Subscription) error
+	GetByID(context.Context, uuid.UUID) (*model.Subscription, error)
+	Update(context.Context, uuid.UUID, *model.Subscription) error
+	Delete(context.Context, uuid.UUID) error
+	List(context.Context, ListQuery) ([]model.Subscription, error)
+	Search(context.Context, string, *uuid.UUID, int) ([]model.Subscription, error)
+	Expand(context.Context, []model.Subscription, []string) (*Expansions, error)
+	AggregateTotalCost(context.Context, AggregateFilter) (int64, error)
+	Pause(context.Context, uuid.UUID, time.Time) (*model.Subscription, error)
+	Resume(context.Context, uuid.UUID, time.Time) (*model.Subscription, error)
+	Cancel(context.Context, uuid.UUID, time.Time) (*model.Subscription, error)
+	History(context.Context, uuid.UUID) ([]model.SubscriptionEvent, error)
+	ForTenant(string) SubscriptionServiceInterface
 }
 
+// SubscriptionFilterSchema is the whitelist of model.Subscription fields usable in filter= expressions.
+var SubscriptionFilterSchema = filter.Schema{
+	"id":           {Column: "id", Type: filter.UUID},
+	"service_name": {Column: "service_name", Type: filter.String},
+	"status":       {Column: "status", Type: filter.String},
+	"price":        {Column: "price", Type: filter.Int},
+	"user_id":      {Column: "user_id", Type: filter.UUID},
+	"start_date":   {Column: "start_date", Type: filter.Date},
+	"end_date":     {Column: "end_date", Type: filter.Date, Nullable: true},
+	"created_at":   {Column: "created_at", Type: filter.Timestamp},
+	"updated_at":   {Column: "updated_at", Type: filter.Timestamp},
+}
+
+// ListQuery selects a page of subscriptions. Filter holds exact column matches,
+// Expr is an optional parsed filter= expression validated against SubscriptionFilterSchema.
+// Status is one of model.Statuses, the derived ones included.
+// Fields restricts the selected columns (JSON field names), all columns are selected when empty.
+type ListQuery struct {
+	Filter map[string]interface{}
+	Expr   filter.Node
+	Status string
+	Fields []string
+	Limit  int
+	Offset int
+}
+
+// AggregateFilter narrows down the subscriptions taken into account by AggregateTotalCost.
+// ServiceName is an exact match, ServiceNameLike is the same fuzzy match as Search,
+// Category matches services listed under that category in the services catalog.
+// Attribution decides what is counted for UserID: the full price of the subscriptions the user pays for
+// (AttributionPayer, the default) or the user's share of every subscription they pay for or are a member of.
+type AggregateFilter struct {
+	PeriodStart     time.Time
+	PeriodEnd       time.Time
+	UserID          *uuid.UUID
+	ServiceName     *string
+	ServiceNameLike *string
+	Category        *string
+	Expr            filter.Node
+	Attribution     string
+}
+
+const (
+	AttributionPayer = "payer"
+	AttributionShare = "share"
+)
+
+// SubscriptionService as returned by NewSubscriptionService sees the subscriptions of every tenant
+// and creates new ones in the default tenant; ForTenant narrows it down to one tenant.
 type SubscriptionService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	root     *gorm.DB
+	tenantID string
+	trgm     *extensionProbe
+}
+
+// extensionProbe remembers whether a postgres extension is installed, so it is checked once per service.
+// A check that failed, e.g. while the database was unavailable, is repeated on the next use.
+type extensionProbe struct {
+	mu        sync.Mutex
+	checked   bool
+	installed bool
 }
 
 var ErrSubscriptionNotFound = errors.New("subscription not found")
 
 func NewSubscriptionService(db *gorm.DB) *SubscriptionService {
-	return &SubscriptionService{db: db}
+	return &SubscriptionService{db: db, root: db, trgm: &extensionProbe{}}
 }
 
-func (s *SubscriptionService) Create(sub *model.Subscription) error {
-	return s.db.Create(sub).Error
+// ForTenant returns the service restricted to the tenant's rows, tracing each call.
+func (s *SubscriptionService) ForTenant(tenantID string) SubscriptionServiceInterface {
+	return tracedSubscriptions{s: s.forTenant(tenantID)}
 }
 
-func (s *SubscriptionService) GetByID(id uuid.UUID) (*model.Subscription, error) {
+func (s *SubscriptionService) forTenant(tenantID string) *SubscriptionService {
+	return &SubscriptionService{db: tenant.DB(s.root, tenantID), root: s.root, tenantID: tenantID, trgm: s.trgm}
+}
+
+func (s *SubscriptionService) Create(ctx context.Context, sub *model.Subscription) error {
+	sub.TenantID = tenant.OrDefault(s.tenantID)
+	sub.Status = model.StatusActive
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sub).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, model.EventSubscriptionCreated, sub)
+	})
+	if err == nil {
+		metrics.SubscriptionsCreated.Inc()
+	}
+	return err
+}
+
+func (s *SubscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
 	var sub model.Subscription
-	if err := s.db.First(&sub, "id = ?", id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&sub, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &sub, nil
 }
 
-func (s *SubscriptionService) Update(id uuid.UUID, updated *model.Subscription) error {
+func (s *SubscriptionService) Update(ctx context.Context, id uuid.UUID, updated *model.Subscription) error {
 	updated.ID = id
 
-	tx := s.db.Model(&model.Subscription{}).Where("id = ?", id).Updates(updated)
-	if tx.Error != nil {
-		return tx.Error
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// status only changes through Pause, Resume and Cancel
+		res := tx.Model(&model.Subscription{}).Where("id = ?", id).Omit("status", "tenant_id").Updates(updated)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrSubscriptionNotFound
+		}
+
+		var current model.Subscription
+		if err := tx.First(&current, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, model.EventSubscriptionUpdated, &current)
+	})
+	if err == nil {
+		metrics.SubscriptionsUpdated.Inc()
 	}
-	if tx.RowsAffected == 0 {
-		return ErrSubscriptionNotFound
+	return err
+}
+
+func (s *SubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var deleted model.Subscription
+		res := tx.Clauses(clause.Returning{}).Where("id = ?", id).Delete(&deleted)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrSubscriptionNotFound
+		}
+		return recordEvent(tx, model.EventSubscriptionDeleted, &deleted)
+	})
+	if err == nil {
+		metrics.SubscriptionsDeleted.Inc()
 	}
-	return nil
+	return err
 }
 
-func (s *SubscriptionService) Delete(id uuid.UUID) error {
-	tx := s.db.Delete(&model.Subscription{}, "id = ?", id)
-	if tx.Error != nil {
-		return tx.Error
+func (s *SubscriptionService) List(ctx context.Context, q ListQuery) ([]model.Subscription, error) {
+	var subs []model.Subscription
+	tx := s.db.WithContext(ctx).Model(&model.Subscription{})
+	for k, v := range q.Filter {
+		tx = tx.Where(k+" = ?", v)
+	}
+	if q.Expr != nil {
+		cond, args, err := SubscriptionFilterSchema.ToSQL(q.Expr)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(cond, args...)
+	}
+	if q.Status != "" {
+		cond, args, err := statusCondition(q.Status, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(cond, args...)
+	}
+	if len(q.Fields) > 0 {
+		cols, err := SubscriptionColumns(q.Fields)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Select(cols)
+	}
+	if q.Limit > 0 {
+		tx = tx.Limit(q.Limit)
+	}
+	if q.Offset > 0 {
+		tx = tx.Offset(q.Offset)
 	}
-	if tx.RowsAffected == 0 {
-		return ErrSubscriptionNotFound
+	if err := tx.Find(&subs).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return subs, nil
 }
 
-func (s *SubscriptionService) List(filter map[string]interface{}, limit, offset int) ([]model.Subscription, error) {
+// Search looks subscriptions up by a possibly misspelled service name. With pg_trgm the results
+// are ranked by trigram similarity, otherwise a case-insensitive prefix match is used.
+// A non-nil userID restricts the results to the subscriptions of that user.
+func (s *SubscriptionService) Search(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]model.Subscription, error) {
 	var subs []model.Subscription
-	tx := s.db.Model(&model.Subscription{})
-	for k, v := range filter {
-		tx = tx.Where(k+" = ?", v)
+	cond, args := s.serviceNameMatch(ctx, query)
+	tx := s.db.WithContext(ctx).Model(&model.Subscription{}).Where(cond, args...)
+	if userID != nil {
+		tx = tx.Where("user_id = ?", *userID)
+	}
+	if s.trgmInstalled(ctx) {
+		tx = tx.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  "similarity(service_name, ?) DESC, service_name",
+			Vars: []interface{}{query},
+		}})
+	} else {
+		tx = tx.Order("service_name")
 	}
 	if limit > 0 {
 		tx = tx.Limit(limit)
 	}
-	if offset > 0 {
-		tx = tx.Offset(offset)
-	}
 	if err := tx.Find(&subs).Error; err != nil {
 		return nil, err
 	}
 	return subs, nil
 }
 
-func (s *SubscriptionService) AggregateTotalCost(periodStart, periodEnd time.Time, userID *uuid.UUID, serviceName *string) (int64, error) {
+func (s *SubscriptionService) trgmInstalled(ctx context.Context) bool {
+	s.trgm.mu.Lock()
+	defer s.trgm.mu.Unlock()
+	if !s.trgm.checked {
+		var installed bool
+		err := s.db.WithContext(ctx).Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'pg_trgm')").Scan(&installed).Error
+		if err != nil {
+			return false
+		}
+		s.trgm.checked, s.trgm.installed = true, installed
+	}
+	return s.trgm.installed
+}
+
+// serviceNameMatch builds the fuzzy service_name condition shared by Search and AggregateTotalCost.
+func (s *SubscriptionService) serviceNameMatch(ctx context.Context, query string) (string, []interface{}) {
+	prefix := escapeLike(strings.ToLower(query)) + "%"
+	if s.trgmInstalled(ctx) {
+		return "(service_name % ? OR LOWER(service_name) LIKE ?)", []interface{}{query, prefix}
+	}
+	return "LOWER(service_name) LIKE ?", []interface{}{prefix}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// AggregateTotalCost sums the monthly price over the months of the period in which each subscription
+// is charged. Months fully covered by a pause are not charged.
+func (s *SubscriptionService) AggregateTotalCost(ctx context.Context, f AggregateFilter) (int64, error) {
+	periodStart, periodEnd := f.PeriodStart, f.PeriodEnd
+	share := f.UserID != nil && f.Attribution == AttributionShare
+
+	price := "price"
+	if share {
+		price = "sh.amount"
+	}
 	sql := `
-SELECT COALESCE(SUM(
-    ((DATE_PART('year', LEAST(COALESCE(end_date, ?), ?)) * 12 + DATE_PART('month', LEAST(COALESCE(end_date, ?), ?)))
-     -
-    (DATE_PART('year', GREATEST(start_date, ?)) * 12 + DATE_PART('month', GREATEST(start_date, ?)))
-     + 1) * price
-),0)::bigint as total
+SELECT COALESCE(SUM((w.last_month - w.first_month + 1 - COALESCE(p.paused_months, 0)) * ` + price + `), 0)::bigint as total
 FROM subscriptions
-WHERE start_date <= ? AND (end_date IS NULL OR end_date >= ?)
+CROSS JOIN LATERAL (SELECT
+    DATE_PART('year', GREATEST(start_date, ?)) * 12 + DATE_PART('month', GREATEST(start_date, ?)) AS first_month,
+    DATE_PART('year', LEAST(COALESCE(end_date, ?), ?)) * 12 + DATE_PART('month', LEAST(COALESCE(end_date, ?), ?)) AS last_month
+) w
+LEFT JOIN LATERAL (
+    SELECT SUM(GREATEST(0, LEAST(pm.last_month, w.last_month) - GREATEST(pm.first_month, w.first_month) + 1)) AS paused_months
+    FROM (
+        SELECT
+            DATE_PART('year', sp.paused_at) * 12 + DATE_PART('month', sp.paused_at)
+                + CASE WHEN DATE_PART('day', sp.paused_at) = 1 THEN 0 ELSE 1 END AS first_month,
+            COALESCE(DATE_PART('year', sp.resumed_at) * 12 + DATE_PART('month', sp.resumed_at) - 1, w.last_month) AS last_month
+        FROM subscription_pauses sp
+        WHERE sp.subscription_id = subscriptions.id
+    ) pm
+) p ON TRUE
+`
+	args := []interface{}{periodStart, periodStart, periodEnd, periodEnd, periodEnd, periodEnd}
+
+	if share {
+		// same split as MemberShares: fixed amounts, then the rest by weight, the payer covers the remainder
+		sql += `CROSS JOIN LATERAL (
+    SELECT CASE WHEN subscriptions.user_id = ?
+                THEN subscriptions.price - COALESCE(SUM(ms.share) FILTER (WHERE ms.user_id <> ?), 0)
+                ELSE COALESCE(SUM(ms.share) FILTER (WHERE ms.user_id = ?), 0)
+           END AS amount
+    FROM (
+        SELECT sm.user_id, COALESCE(sm.amount,
+            GREATEST(subscriptions.price - COALESCE(SUM(sm.amount) OVER (), 0), 0) * sm.weight / NULLIF(SUM(sm.weight) OVER (), 0)) AS share
+        FROM subscription_members sm
+        WHERE sm.subscription_id = subscriptions.id
+    ) ms
+) sh
 `
+		args = append(args, *f.UserID, *f.UserID, *f.UserID)
+	}
 
-	args := []interface{}{periodEnd, periodEnd, periodEnd, periodEnd, periodStart, periodStart, periodEnd, periodStart}
+	sql += "WHERE start_date <= ? AND (end_date IS NULL OR end_date >= ?)"
+	args = append(args, periodEnd, periodStart)
+	if s.tenantID != "" {
+		// raw SQL is not covered by the tenant scope of s.db
+		sql += " AND subscriptions.tenant_id = ?"
+		args = append(args, s.tenantID)
+	}
 
 	// динамические фильтры
-	if userID != nil {
+	if share {
+		sql += " AND (user_id = ? OR EXISTS (SELECT 1 FROM subscription_members sm WHERE sm.subscription_id = subscriptions.id AND sm.user_id = ?))"
+		args = append(args, *f.UserID, *f.UserID)
+	} else if f.UserID != nil {
 		sql += " AND user_id = ?"
-		args = append(args, *userID)
+		args = append(args, *f.UserID)
 	}
-	if serviceName != nil {
+	if f.ServiceName != nil {
 		sql += " AND service_name = ?"
-		args = append(args, *serviceName)
+		args = append(args, *f.ServiceName)
+	}
+	if f.ServiceNameLike != nil {
+		cond, condArgs := s.serviceNameMatch(ctx, *f.ServiceNameLike)
+		sql += " AND " + cond
+		args = append(args, condArgs...)
+	}
+	if f.Category != nil {
+		sql += " AND service_name IN (SELECT name FROM services WHERE category = ? AND services.tenant_id = subscriptions.tenant_id)"
+		args = append(args, *f.Category)
+	}
+	if f.Expr != nil {
+		cond, condArgs, err := SubscriptionFilterSchema.ToSQL(f.Expr)
+		if err != nil {
+			return 0, err
+		}
+		sql += " AND " + cond
+		args = append(args, condArgs...)
 	}
 
 	var total int64
-	if err := s.db.Raw(sql, args...).Scan(&total).Error; err != nil {
+	if err := s.db.WithContext(ctx).Raw(sql, args...).Scan(&total).Error; err != nil {
 		return 0, err
 	}
 
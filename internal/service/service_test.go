@@ -1,9 +1,13 @@
 package service
 
 import (
+	"REST-service-sub/internal/filter"
+	"REST-service-sub/internal/metrics"
 	"REST-service-sub/internal/model"
+	"context"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"testing"
@@ -23,6 +27,17 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
+func TestTrgmProbe_RetriedAfterFailure(t *testing.T) {
+	// nothing listens on the port, every query fails
+	db, err := gorm.Open(postgres.Open("postgres://postgres@127.0.0.1:1/none?sslmode=disable&connect_timeout=1"),
+		&gorm.Config{DisableAutomaticPing: true})
+	require.NoError(t, err)
+	svc := NewSubscriptionService(db)
+
+	assert.False(t, svc.trgmInstalled(context.Background()))
+	assert.False(t, svc.trgm.checked, "неудачная проверка не должна запоминаться")
+}
+
 func TestCRUD(t *testing.T) {
 	db := setupTestDB(t)
 	svc := NewSubscriptionService(db)
@@ -34,28 +49,28 @@ func TestCRUD(t *testing.T) {
 		UserID:      uuid.New(),
 		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
 	}
-	err := svc.Create(sub)
+	err := svc.Create(context.Background(), sub)
 	assert.NoError(t, err)
 
-	got, err := svc.GetByID(sub.ID)
+	got, err := svc.GetByID(context.Background(), sub.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, sub.ServiceName, got.ServiceName)
 
 	sub.Price = 500
-	err = svc.Update(sub.ID, sub)
+	err = svc.Update(context.Background(), sub.ID, sub)
 	assert.NoError(t, err)
 
-	got, _ = svc.GetByID(sub.ID)
+	got, _ = svc.GetByID(context.Background(), sub.ID)
 	assert.Equal(t, 500, got.Price)
 
-	list, err := svc.List(nil, 10, 0)
+	list, err := svc.List(context.Background(), ListQuery{Limit: 10})
 	assert.NoError(t, err)
 	assert.Len(t, list, 1)
 
-	err = svc.Delete(sub.ID)
+	err = svc.Delete(context.Background(), sub.ID)
 	assert.NoError(t, err)
 
-	_, err = svc.GetByID(sub.ID)
+	_, err = svc.GetByID(context.Background(), sub.ID)
 	assert.Error(t, err)
 }
 
@@ -76,7 +91,141 @@ func TestAggregateTotalCost(t *testing.T) {
 	from := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
 	to := time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC)
 
-	total, err := svc.AggregateTotalCost(from, to, nil, nil)
+	total, err := svc.AggregateTotalCost(context.Background(), AggregateFilter{PeriodStart: from, PeriodEnd: to})
 	assert.NoError(t, err)
 	assert.Equal(t, int64(400), total)
 }
+
+func TestSearch(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+
+	for _, name := range []string{"Netflix", "Yandex Plus", "Spotify"} {
+		db.Create(&model.Subscription{
+			ID:          uuid.New(),
+			ServiceName: name,
+			Price:       400,
+			UserID:      uuid.New(),
+			StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	found, err := svc.Search(context.Background(), "yandex", nil, 10)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, found) {
+		assert.Equal(t, "Yandex Plus", found[0].ServiceName)
+	}
+
+	if svc.trgmInstalled(context.Background()) {
+		found, err = svc.Search(context.Background(), "netflx", nil, 10)
+		assert.NoError(t, err)
+		if assert.NotEmpty(t, found) {
+			assert.Equal(t, "Netflix", found[0].ServiceName)
+		}
+	}
+
+	like := "yandex"
+	total, err := svc.AggregateTotalCost(context.Background(), AggregateFilter{
+		PeriodStart:     time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:       time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC),
+		ServiceNameLike: &like,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(400), total)
+}
+
+func TestListAndAggregate_FilterExpression(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+
+	userID := uuid.New()
+	start := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	for _, sub := range []model.Subscription{
+		{ServiceName: "Netflix", Price: 600, UserID: userID, StartDate: start},
+		{ServiceName: "Spotify", Price: 300, UserID: userID, StartDate: start},
+		{ServiceName: "Netflix", Price: 700, UserID: userID, StartDate: start, EndDate: &end},
+		{ServiceName: "Yandex Plus", Price: 400, UserID: userID, StartDate: start},
+	} {
+		sub.ID = uuid.New()
+		assert.NoError(t, db.Create(&sub).Error)
+	}
+
+	expr, err := filter.Parse("price=gt=500;service_name=in=(Netflix,Spotify);end_date=isnull=true")
+	assert.NoError(t, err)
+
+	list, err := svc.List(context.Background(), ListQuery{Expr: expr, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, 600, list[0].Price)
+	}
+
+	total, err := svc.AggregateTotalCost(context.Background(), AggregateFilter{
+		PeriodStart: start,
+		PeriodEnd:   time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC),
+		Expr:        expr,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(600), total)
+}
+
+func TestListFieldsAndExpand(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+
+	user := model.User{ID: uuid.New(), Name: "Ivan"}
+	assert.NoError(t, db.Create(&user).Error)
+	t.Cleanup(func() { db.Delete(&user) })
+
+	sub := &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Unlisted Service " + uuid.NewString(),
+		Price:       400,
+		UserID:      user.ID,
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, svc.Create(context.Background(), sub))
+
+	list, err := svc.List(context.Background(), ListQuery{Fields: []string{"id", "price", "user_id"}, Limit: 10})
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, sub.ID, list[0].ID)
+		assert.Equal(t, 400, list[0].Price)
+		assert.Empty(t, list[0].ServiceName)
+	}
+
+	_, err = svc.List(context.Background(), ListQuery{Fields: []string{"password"}})
+	assert.Error(t, err)
+
+	exp, err := svc.Expand(context.Background(), []model.Subscription{*sub}, []string{ExpandUser, ExpandService})
+	assert.NoError(t, err)
+	assert.Equal(t, "Ivan", exp.Users[user.ID].Name)
+	assert.Empty(t, exp.Services)
+}
+
+func TestEscapeLike(t *testing.T) {
+	assert.Equal(t, `100\% \_x\\`, escapeLike(`100% _x\`))
+}
+
+func TestTenantStats(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+	now := time.Now()
+	start := monthOf(now).AddDate(0, -1, 0)
+
+	for i, tenantID := range []string{"acme", "acme", "globex"} {
+		sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 100 * (i + 1), UserID: uuid.New(), StartDate: start}
+		require.NoError(t, svc.ForTenant(tenantID).Create(context.Background(), sub))
+	}
+	future := &model.Subscription{ID: uuid.New(), ServiceName: "Spotify", Price: 999, UserID: uuid.New(), StartDate: start.AddDate(1, 0, 0)}
+	require.NoError(t, svc.ForTenant("acme").Create(context.Background(), future))
+
+	stats, err := svc.TenantStats(context.Background(), now)
+	require.NoError(t, err)
+	byTenant := map[string]metrics.TenantStats{}
+	for _, s := range stats {
+		byTenant[s.TenantID] = s
+	}
+	assert.Equal(t, metrics.TenantStats{TenantID: "acme", Active: 2, MonthlyCost: 300}, byTenant["acme"])
+	assert.Equal(t, metrics.TenantStats{TenantID: "globex", Active: 1, MonthlyCost: 300}, byTenant["globex"])
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"REST-service-sub/internal/metrics"
+	"REST-service-sub/internal/model"
+	"context"
+	"time"
+)
+
+// TenantStats returns the active subscriptions and their monthly cost of every tenant, for the
+// business gauges.
+func (s *SubscriptionService) TenantStats(ctx context.Context, now time.Time) ([]metrics.TenantStats, error) {
+	cond, args, err := statusCondition(model.StatusActive, now)
+	if err != nil {
+		return nil, err
+	}
+	var stats []metrics.TenantStats
+	err = s.root.WithContext(ctx).Model(&model.Subscription{}).
+		Select("tenant_id, COUNT(*) AS active, COALESCE(SUM(price), 0) AS monthly_cost").
+		Where(cond, args...).
+		Group("tenant_id").
+		Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"time"
+)
+
+// ErrInvalidTransition is returned when pause, resume or cancel is not allowed in the current status.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions is the subscription state machine: the statuses each action may start from.
+var transitions = map[string][]string{
+	model.StatusPaused:    {model.StatusActive},
+	model.StatusActive:    {model.StatusPaused},
+	model.StatusCancelled: {model.StatusActive, model.StatusPaused},
+}
+
+// Pause stops charging the subscription from the first month fully covered by the pause.
+func (s *SubscriptionService) Pause(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	return s.transition(ctx, id, model.StatusPaused, model.EventSubscriptionPaused, func(tx *gorm.DB, sub *model.Subscription) error {
+		day := dateOf(at)
+		if sub.EndDate != nil && sub.EndDate.Before(monthOf(day)) {
+			return fmt.Errorf("%w: subscription has ended", ErrInvalidTransition)
+		}
+		return tx.Create(&model.SubscriptionPause{SubscriptionID: sub.ID, TenantID: sub.TenantID, PausedAt: day}).Error
+	})
+}
+
+// Resume closes the open pause; the month of resumption is charged.
+func (s *SubscriptionService) Resume(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	return s.transition(ctx, id, model.StatusActive, model.EventSubscriptionResumed, func(tx *gorm.DB, sub *model.Subscription) error {
+		return tx.Model(&model.SubscriptionPause{}).
+			Where("subscription_id = ? AND resumed_at IS NULL", sub.ID).
+			Update("resumed_at", dateOf(at)).Error
+	})
+}
+
+// Cancel ends the subscription with the current month, the last one charged. A subscription
+// that has not started yet is never charged. An open pause stays open, so a paused
+// subscription is not charged for the month it is cancelled in.
+func (s *SubscriptionService) Cancel(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	return s.transition(ctx, id, model.StatusCancelled, model.EventSubscriptionCancelled, func(tx *gorm.DB, sub *model.Subscription) error {
+		end := monthOf(dateOf(at))
+		if sub.StartDate.After(end) {
+			// an end month before the start month makes the billed month count zero
+			end = monthOf(sub.StartDate).AddDate(0, -1, 0)
+		}
+		if sub.EndDate != nil && sub.EndDate.Before(end) {
+			return nil
+		}
+		sub.EndDate = &end
+		return tx.Model(&model.Subscription{}).Where("id = ?", sub.ID).Update("end_date", end).Error
+	})
+}
+
+func (s *SubscriptionService) transition(ctx context.Context, id uuid.UUID, to, eventType string, apply func(tx *gorm.DB, sub *model.Subscription) error) (*model.Subscription, error) {
+	var sub model.Subscription
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSubscriptionNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(transitions[to], sub.Status) {
+			return fmt.Errorf("%w: subscription is %s", ErrInvalidTransition, sub.Status)
+		}
+		if err := apply(tx, &sub); err != nil {
+			return err
+		}
+		if err := tx.Model(&sub).Update("status", to).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, eventType, &sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// statusCondition translates a status filter into SQL. Scheduled and ended are derived from the
+// dates of non-cancelled subscriptions, active and paused only match subscriptions running today.
+func statusCondition(status string, now time.Time) (string, []interface{}, error) {
+	today := dateOf(now)
+	month := monthOf(today)
+	running := "start_date <= ? AND (end_date IS NULL OR end_date >= ?)"
+	switch status {
+	case model.StatusActive, model.StatusPaused:
+		return "status = ? AND " + running, []interface{}{status, today, month}, nil
+	case model.StatusCancelled:
+		return "status = ?", []interface{}{status}, nil
+	case model.StatusScheduled:
+		return "status <> ? AND start_date > ?", []interface{}{model.StatusCancelled, today}, nil
+	case model.StatusEnded:
+		return "status <> ? AND end_date < ?", []interface{}{model.StatusCancelled, month}, nil
+	default:
+		return "", nil, fmt.Errorf("unknown status %q", status)
+	}
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// monthOf returns the first day of the month, the granularity of start_date and end_date.
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+
+	month := func(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+	userID := uuid.New()
+	sub := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 100, UserID: userID, StartDate: month(2025, 1)}
+	require.NoError(t, svc.Create(context.Background(), sub))
+	assert.Equal(t, model.StatusActive, sub.Status)
+
+	total := func() int64 {
+		cost, err := svc.AggregateTotalCost(context.Background(), AggregateFilter{PeriodStart: month(2025, 1), PeriodEnd: month(2025, 12), UserID: &userID})
+		require.NoError(t, err)
+		return cost
+	}
+	assert.Equal(t, int64(1200), total())
+
+	// paused on 10 March, resumed on 5 June: April and May are free
+	_, err := svc.Resume(context.Background(), sub.ID, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	paused, err := svc.Pause(context.Background(), sub.ID, time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPaused, paused.Status)
+	_, err = svc.Pause(context.Background(), sub.ID, time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = svc.Resume(context.Background(), sub.ID, time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), total())
+
+	// paused again since 1 September and cancelled in October while paused: charged January-August but April and May
+	_, err = svc.Pause(context.Background(), sub.ID, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	cancelled, err := svc.Cancel(context.Background(), sub.ID, time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusCancelled, cancelled.Status)
+	if assert.NotNil(t, cancelled.EndDate) {
+		assert.True(t, cancelled.EndDate.Equal(month(2025, 10)))
+	}
+	assert.Equal(t, int64(600), total())
+	_, err = svc.Resume(context.Background(), sub.ID, time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+
+	_, err = svc.Cancel(context.Background(), uuid.New(), time.Now())
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+	var events []model.SubscriptionEvent
+	require.NoError(t, db.Where("subscription_id = ?", sub.ID).Order("id").Find(&events).Error)
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{
+		model.EventSubscriptionCreated,
+		model.EventSubscriptionPaused,
+		model.EventSubscriptionResumed,
+		model.EventSubscriptionPaused,
+		model.EventSubscriptionCancelled,
+	}, types)
+}
+
+func TestListByStatus(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewSubscriptionService(db)
+
+	now := time.Now()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastYear := thisMonth.AddDate(-1, 0, 0)
+	userID := uuid.New()
+	create := func(start time.Time, end *time.Time) *model.Subscription {
+		sub := &model.Subscription{ID: uuid.New(), ServiceName: "Spotify", Price: 300, UserID: userID, StartDate: start, EndDate: end}
+		require.NoError(t, svc.Create(context.Background(), sub))
+		return sub
+	}
+	active := create(lastYear, nil)
+	scheduled := create(thisMonth.AddDate(0, 2, 0), nil)
+	ended := create(lastYear, &lastYear)
+	paused := create(lastYear, nil)
+	_, err := svc.Pause(context.Background(), paused.ID, now)
+	require.NoError(t, err)
+
+	for status, want := range map[string]uuid.UUID{
+		model.StatusActive:    active.ID,
+		model.StatusScheduled: scheduled.ID,
+		model.StatusEnded:     ended.ID,
+		model.StatusPaused:    paused.ID,
+	} {
+		subs, err := svc.List(context.Background(), ListQuery{Filter: map[string]interface{}{"user_id": userID}, Status: status})
+		require.NoError(t, err)
+		if assert.Len(t, subs, 1, status) {
+			assert.Equal(t, want, subs[0].ID, status)
+		}
+	}
+}
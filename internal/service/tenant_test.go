@@ -0,0 +1,109 @@
+package service
+
+import (
+	"REST-service-sub/internal/filter"
+	"REST-service-sub/internal/model"
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	db := setupTestDB(t)
+	root := NewSubscriptionService(db)
+	acme := root.ForTenant("acme")
+	globex := root.ForTenant("globex")
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	mine := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 700, UserID: userID, StartDate: start}
+	theirs := &model.Subscription{ID: uuid.New(), ServiceName: "Netflix", Price: 500, UserID: userID, StartDate: start}
+	require.NoError(t, acme.Create(context.Background(), mine))
+	require.NoError(t, globex.Create(context.Background(), theirs))
+	assert.Equal(t, "acme", mine.TenantID)
+
+	// reads
+	_, err := acme.GetByID(context.Background(), theirs.ID)
+	assert.Error(t, err)
+	list, err := acme.List(context.Background(), ListQuery{Filter: map[string]interface{}{"user_id": userID}})
+	require.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, mine.ID, list[0].ID)
+	}
+	expr, err := filter.Parse("service_name==Netflix")
+	require.NoError(t, err)
+	list, err = acme.List(context.Background(), ListQuery{Expr: expr})
+	require.NoError(t, err)
+	for _, sub := range list {
+		assert.NotEqual(t, theirs.ID, sub.ID)
+	}
+	found, err := acme.Search(context.Background(), "Netflix", nil, 100)
+	require.NoError(t, err)
+	for _, sub := range found {
+		assert.NotEqual(t, theirs.ID, sub.ID)
+	}
+
+	// aggregates, with and without share attribution
+	for _, attribution := range []string{AttributionPayer, AttributionShare} {
+		total, err := acme.AggregateTotalCost(context.Background(), AggregateFilter{
+			PeriodStart: start, PeriodEnd: start, UserID: &userID, Attribution: attribution,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(700), total, attribution)
+	}
+	total, err := globex.AggregateTotalCost(context.Background(), AggregateFilter{PeriodStart: start, PeriodEnd: start, UserID: &userID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), total)
+
+	// writes
+	err = acme.Update(context.Background(), theirs.ID, &model.Subscription{Price: 1})
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	err = acme.Delete(context.Background(), theirs.ID)
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	_, err = acme.Cancel(context.Background(), theirs.ID, time.Now())
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+	_, err = NewMemberService(db).ForTenant("acme").SetMembers(context.Background(), theirs.ID, nil)
+	assert.ErrorIs(t, err, ErrSubscriptionNotFound)
+
+	got, err := globex.GetByID(context.Background(), theirs.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 500, got.Price)
+
+	// events and webhook deliveries stay within the tenant
+	events, err := NewEventBroker(db).Since(context.Background(), "acme", 0, &userID, 10)
+	require.NoError(t, err)
+	for _, e := range events {
+		assert.Equal(t, "acme", e.TenantID)
+		assert.Equal(t, mine.ID, e.SubscriptionID)
+	}
+}
+
+func TestTenantIsolation_Budgets(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	budgets := NewBudgetService(db, subs)
+
+	now := time.Now()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	require.NoError(t, subs.ForTenant("globex").Create(context.Background(), &model.Subscription{
+		ID: uuid.New(), ServiceName: "Spotify", Price: 900, UserID: userID, StartDate: month,
+	}))
+	t.Cleanup(func() { db.Where("user_id = ?", userID).Delete(&model.Budget{}) })
+
+	acme := budgets.ForTenant("acme")
+	require.NoError(t, acme.SetBudget(context.Background(), &model.Budget{UserID: userID, MonthlyLimit: 100}))
+	statuses, err := acme.Status(context.Background(), userID, now)
+	require.NoError(t, err)
+	if assert.Len(t, statuses, 1) {
+		assert.Equal(t, int64(0), statuses[0].Spent)
+		assert.False(t, statuses[0].Exceeded)
+	}
+
+	list, err := budgets.ForTenant("globex").ListBudgets(context.Background(), userID)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
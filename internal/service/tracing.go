@@ -0,0 +1,111 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"REST-service-sub/internal/tenant"
+	"REST-service-sub/internal/tracing"
+	"context"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// tracedSubscriptions runs every call of SubscriptionServiceInterface in its own span, a child of
+// the span in the context of the call.
+type tracedSubscriptions struct {
+	s *SubscriptionService
+}
+
+func (t tracedSubscriptions) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "SubscriptionService."+method,
+		trace.WithAttributes(attribute.String("tenant.id", tenant.OrDefault(t.s.tenantID))))
+}
+
+func (t tracedSubscriptions) Create(ctx context.Context, sub *model.Subscription) error {
+	ctx, span := t.start(ctx, "Create")
+	err := t.s.Create(ctx, sub)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedSubscriptions) GetByID(ctx context.Context, id uuid.UUID) (*model.Subscription, error) {
+	ctx, span := t.start(ctx, "GetByID")
+	sub, err := t.s.GetByID(ctx, id)
+	tracing.End(span, err)
+	return sub, err
+}
+
+func (t tracedSubscriptions) Update(ctx context.Context, id uuid.UUID, updated *model.Subscription) error {
+	ctx, span := t.start(ctx, "Update")
+	err := t.s.Update(ctx, id, updated)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedSubscriptions) Delete(ctx context.Context, id uuid.UUID) error {
+	ctx, span := t.start(ctx, "Delete")
+	err := t.s.Delete(ctx, id)
+	tracing.End(span, err)
+	return err
+}
+
+func (t tracedSubscriptions) List(ctx context.Context, q ListQuery) ([]model.Subscription, error) {
+	ctx, span := t.start(ctx, "List")
+	subs, err := t.s.List(ctx, q)
+	tracing.End(span, err)
+	return subs, err
+}
+
+func (t tracedSubscriptions) Search(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]model.Subscription, error) {
+	ctx, span := t.start(ctx, "Search")
+	subs, err := t.s.Search(ctx, query, userID, limit)
+	tracing.End(span, err)
+	return subs, err
+}
+
+func (t tracedSubscriptions) Expand(ctx context.Context, subs []model.Subscription, relations []string) (*Expansions, error) {
+	ctx, span := t.start(ctx, "Expand")
+	exp, err := t.s.Expand(ctx, subs, relations)
+	tracing.End(span, err)
+	return exp, err
+}
+
+func (t tracedSubscriptions) AggregateTotalCost(ctx context.Context, f AggregateFilter) (int64, error) {
+	ctx, span := t.start(ctx, "AggregateTotalCost")
+	total, err := t.s.AggregateTotalCost(ctx, f)
+	tracing.End(span, err)
+	return total, err
+}
+
+func (t tracedSubscriptions) Pause(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	ctx, span := t.start(ctx, "Pause")
+	sub, err := t.s.Pause(ctx, id, at)
+	tracing.End(span, err)
+	return sub, err
+}
+
+func (t tracedSubscriptions) Resume(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	ctx, span := t.start(ctx, "Resume")
+	sub, err := t.s.Resume(ctx, id, at)
+	tracing.End(span, err)
+	return sub, err
+}
+
+func (t tracedSubscriptions) Cancel(ctx context.Context, id uuid.UUID, at time.Time) (*model.Subscription, error) {
+	ctx, span := t.start(ctx, "Cancel")
+	sub, err := t.s.Cancel(ctx, id, at)
+	tracing.End(span, err)
+	return sub, err
+}
+
+func (t tracedSubscriptions) History(ctx context.Context, id uuid.UUID) ([]model.SubscriptionEvent, error) {
+	ctx, span := t.start(ctx, "History")
+	events, err := t.s.History(ctx, id)
+	tracing.End(span, err)
+	return events, err
+}
+
+func (t tracedSubscriptions) ForTenant(tenantID string) SubscriptionServiceInterface {
+	return t.s.ForTenant(tenantID)
+}
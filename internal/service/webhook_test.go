@@ -0,0 +1,222 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"type":"subscription.created"}`)
+	sig := SignWebhookPayload("secret", 1700000000, body)
+
+	assert.Equal(t, "sha256=", sig[:7])
+	assert.True(t, hmac.Equal([]byte(sig), []byte(SignWebhookPayload("secret", 1700000000, body))))
+	assert.NotEqual(t, sig, SignWebhookPayload("other", 1700000000, body))
+	assert.NotEqual(t, sig, SignWebhookPayload("secret", 1700000001, body))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	base, max := 30*time.Second, 10*time.Minute
+	assert.Equal(t, 30*time.Second, webhookBackoff(1, base, max))
+	assert.Equal(t, 60*time.Second, webhookBackoff(2, base, max))
+	assert.Equal(t, 4*time.Minute, webhookBackoff(4, base, max))
+	assert.Equal(t, max, webhookBackoff(6, base, max))
+	assert.Equal(t, max, webhookBackoff(100, base, max))
+}
+
+func TestWebhookOutboxDelivery(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	webhooks := NewWebhookService(db)
+
+	var received []*http.Request
+	var bodies [][]byte
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if fail {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	endpoint := &model.WebhookEndpoint{URL: srv.URL, EventTypes: []string{model.EventSubscriptionCreated}}
+	require.NoError(t, webhooks.CreateEndpoint(context.Background(), endpoint))
+	t.Cleanup(func() { _ = webhooks.DeleteEndpoint(context.Background(), endpoint.ID) })
+
+	sub := &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       600,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, subs.Create(context.Background(), sub))
+	require.NoError(t, subs.Delete(context.Background(), sub.ID))
+
+	// only the created event matches the endpoint filter
+	deliveries, err := webhooks.ListDeliveries(context.Background(), endpoint.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.EventSubscriptionCreated, deliveries[0].EventType)
+
+	worker := NewWebhookWorker(db, WebhookWorkerConfig{
+		MaxAttempts: 2,
+		BackoffBase: time.Hour,
+		BackoffMax:  time.Hour,
+		Timeout:     time.Second,
+	})
+
+	n, err := worker.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	d, err := webhooks.GetDelivery(context.Background(), endpoint.ID, deliveries[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.True(t, d.NextAttemptAt.After(time.Now().Add(50*time.Minute)))
+
+	// redelivery makes it due immediately and the second answer succeeds
+	fail = false
+	_, err = webhooks.Redeliver(context.Background(), endpoint.ID, d.ID)
+	require.NoError(t, err)
+	n, err = worker.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	d, err = webhooks.GetDelivery(context.Background(), endpoint.ID, d.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryDelivered, d.Status)
+	assert.Len(t, d.History, 2)
+
+	require.Len(t, received, 2)
+	last := received[1]
+	ts, err := strconv.ParseInt(last.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, SignWebhookPayload(endpoint.Secret, ts, bodies[1]), last.Header.Get("X-Webhook-Signature"))
+	assert.Equal(t, model.EventSubscriptionCreated, last.Header.Get("X-Webhook-Event"))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(bodies[1], &payload))
+	assert.Equal(t, sub.ID.String(), payload["data"].(map[string]interface{})["id"])
+}
+
+func TestWebhookDelivery_CancelledIsNotAnAttempt(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	webhooks := NewWebhookService(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the worker shuts down while the endpoint is answering
+		cancel()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	endpoint := &model.WebhookEndpoint{URL: srv.URL}
+	require.NoError(t, webhooks.CreateEndpoint(context.Background(), endpoint))
+	t.Cleanup(func() { _ = webhooks.DeleteEndpoint(context.Background(), endpoint.ID) })
+
+	require.NoError(t, subs.Create(context.Background(), &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Okko",
+		Price:       400,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	worker := NewWebhookWorker(db, WebhookWorkerConfig{MaxAttempts: 1, Timeout: time.Second})
+	_, err := worker.ProcessBatch(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	deliveries, err := webhooks.ListDeliveries(context.Background(), endpoint.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.DeliveryPending, deliveries[0].Status, "прерванная отправка не переводит доставку в dead")
+	assert.Equal(t, 0, deliveries[0].Attempts)
+}
+
+func TestRedeliver_SkipsDeliveryInFlight(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	webhooks := NewWebhookService(db)
+
+	endpoint := &model.WebhookEndpoint{URL: "http://127.0.0.1:1/hooks"}
+	require.NoError(t, webhooks.CreateEndpoint(context.Background(), endpoint))
+	t.Cleanup(func() { _ = webhooks.DeleteEndpoint(context.Background(), endpoint.ID) })
+	require.NoError(t, subs.Create(context.Background(), &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Wink",
+		Price:       300,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	// a worker holds the delivery while it is sending it
+	worker := NewWebhookWorker(db, WebhookWorkerConfig{MaxAttempts: 3, Timeout: time.Minute})
+	claimed, err := worker.claim(context.Background(), time.Now())
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	_, err = webhooks.Redeliver(context.Background(), endpoint.ID, claimed[0].ID)
+	assert.ErrorIs(t, err, ErrDeliveryInFlight)
+	d, err := webhooks.GetDelivery(context.Background(), endpoint.ID, claimed[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryDelivering, d.Status)
+	assert.True(t, d.NextAttemptAt.After(time.Now()), "аренда воркера не должна сбрасываться")
+
+	_, err = webhooks.Redeliver(context.Background(), endpoint.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+}
+
+func TestWebhookDeadLetter(t *testing.T) {
+	db := setupTestDB(t)
+	subs := NewSubscriptionService(db)
+	webhooks := NewWebhookService(db)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	endpoint := &model.WebhookEndpoint{URL: srv.URL}
+	require.NoError(t, webhooks.CreateEndpoint(context.Background(), endpoint))
+	t.Cleanup(func() { _ = webhooks.DeleteEndpoint(context.Background(), endpoint.ID) })
+
+	require.NoError(t, subs.Create(context.Background(), &model.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Spotify",
+		Price:       300,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}))
+
+	worker := NewWebhookWorker(db, WebhookWorkerConfig{MaxAttempts: 1, Timeout: time.Second})
+	_, err := worker.ProcessBatch(context.Background())
+	require.NoError(t, err)
+
+	deliveries, err := webhooks.ListDeliveries(context.Background(), endpoint.ID, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.DeliveryDead, deliveries[0].Status)
+	if assert.NotNil(t, deliveries[0].LastError) {
+		assert.Contains(t, *deliveries[0].LastError, "500")
+	}
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"REST-service-sub/internal/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"io"
+	"net/http"
+	"time"
+)
+
+type WebhookWorkerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	Timeout      time.Duration
+}
+
+// WebhookWorker drains the webhook outbox: it sends pending deliveries, retries failures with
+// exponential backoff and moves deliveries that exhausted MaxAttempts to the dead state.
+type WebhookWorker struct {
+	db     *gorm.DB
+	client *http.Client
+	cfg    WebhookWorkerConfig
+}
+
+// webhookPayload is the JSON body POSTed to endpoints.
+type webhookPayload struct {
+	ID        uuid.UUID       `json:"id"`
+	EventID   int64           `json:"event_id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func NewWebhookWorker(db *gorm.DB, cfg WebhookWorkerConfig) *WebhookWorker {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	return &WebhookWorker{
+		db:     db,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+	}
+}
+
+// Run processes the outbox until ctx is cancelled.
+func (w *WebhookWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			n, err := w.ProcessBatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error().Err(err).Msg("failed to process webhook deliveries")
+				}
+				break
+			}
+			if n < w.cfg.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch sends up to BatchSize due deliveries and returns how many were attempted.
+func (w *WebhookWorker) ProcessBatch(ctx context.Context) (int, error) {
+	batch, err := w.claim(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for i := range batch {
+		if err := w.deliver(ctx, &batch[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(batch), nil
+}
+
+// claim picks due deliveries and leases them by pushing next_attempt_at forward,
+// so concurrent workers on other replicas skip them while they are being sent.
+func (w *WebhookWorker) claim(ctx context.Context, now time.Time) ([]model.WebhookDelivery, error) {
+	var batch []model.WebhookDelivery
+	err := w.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?", []string{model.DeliveryPending, model.DeliveryDelivering}, now).
+			Order("next_attempt_at").
+			Limit(w.cfg.BatchSize).
+			Find(&batch).Error
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		ids := make([]uuid.UUID, len(batch))
+		for i, d := range batch {
+			ids[i] = d.ID
+		}
+		return tx.Model(&model.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"status":          model.DeliveryDelivering,
+				"next_attempt_at": now.Add(2 * w.cfg.Timeout),
+			}).Error
+	})
+	return batch, err
+}
+
+func (w *WebhookWorker) deliver(ctx context.Context, d *model.WebhookDelivery) error {
+	var endpoint model.WebhookEndpoint
+	if err := w.db.WithContext(ctx).First(&endpoint, "id = ?", d.EndpointID).Error; err != nil {
+		return err
+	}
+	var event model.SubscriptionEvent
+	if err := w.db.WithContext(ctx).First(&event, "id = ?", d.EventID).Error; err != nil {
+		return err
+	}
+
+	attempt := model.WebhookDeliveryAttempt{DeliveryID: d.ID, TenantID: d.TenantID, Attempt: d.Attempts + 1}
+	var sendErr error
+	if endpoint.Active {
+		attempt.StatusCode, attempt.DurationMs, sendErr = w.send(ctx, &endpoint, d, &event)
+	} else {
+		sendErr = fmt.Errorf("endpoint is disabled")
+	}
+	if sendErr != nil && ctx.Err() != nil {
+		// cut short by shutdown, not an attempt: the delivery is retried once its lease expires
+		err := w.db.WithContext(context.WithoutCancel(ctx)).Model(&model.WebhookDelivery{}).
+			Where("id = ?", d.ID).Update("status", model.DeliveryPending).Error
+		if err != nil {
+			log.Error().Err(err).Str("delivery_id", d.ID.String()).Msg("failed to release webhook delivery")
+		}
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"attempts": attempt.Attempt}
+	switch {
+	case sendErr == nil:
+		updates["status"] = model.DeliveryDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = nil
+	case attempt.Attempt >= w.cfg.MaxAttempts || !endpoint.Active:
+		msg := sendErr.Error()
+		attempt.Error = &msg
+		updates["status"] = model.DeliveryDead
+		updates["last_error"] = msg
+	default:
+		msg := sendErr.Error()
+		attempt.Error = &msg
+		updates["status"] = model.DeliveryPending
+		updates["next_attempt_at"] = now.Add(webhookBackoff(attempt.Attempt, w.cfg.BackoffBase, w.cfg.BackoffMax))
+		updates["last_error"] = msg
+	}
+
+	if sendErr != nil {
+		log.Warn().Err(sendErr).
+			Str("delivery_id", d.ID.String()).
+			Int("attempt", attempt.Attempt).
+			Msg("webhook delivery failed")
+	}
+
+	// the outcome of a finished attempt is stored even when ctx was cancelled meanwhile
+	return w.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id = ?", d.ID).Updates(updates).Error
+	})
+}
+
+// send POSTs the signed event and treats any 2xx answer as success.
+func (w *WebhookWorker) send(ctx context.Context, endpoint *model.WebhookEndpoint, d *model.WebhookDelivery, event *model.SubscriptionEvent) (*int, int64, error) {
+	body, err := json.Marshal(webhookPayload{
+		ID:        d.ID,
+		EventID:   event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "REST-service-sub-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", d.ID.String())
+	req.Header.Set("X-Webhook-Event", event.Type)
+	req.Header.Set("X-Webhook-Timestamp", fmt.Sprintf("%d", ts))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(endpoint.Secret, ts, body))
+
+	start := time.Now()
+	resp, err := w.client.Do(req)
+	duration := time.Since(start).Milliseconds()
+	if err != nil {
+		return nil, duration, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, duration, fmt.Errorf("endpoint responded with status %d", status)
+	}
+	return &status, duration, nil
+}
+
+// webhookBackoff returns the delay before retry number attempt+1: base, 2*base, 4*base... capped at max.
+func webhookBackoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
@@ -0,0 +1,45 @@
+package tenant
+
+import (
+	"regexp"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default is the tenant of requests that do not name one and of rows created before multi-tenancy.
+const Default = "default"
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Valid reports whether id can be used as a tenant id.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+// Scope restricts queries on the statement's table to the tenant.
+// It does not apply to Raw and Exec: raw SQL has to filter on tenant_id itself.
+func Scope(id string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(clause.Eq{
+			Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"},
+			Value:  id,
+		})
+	}
+}
+
+// DB returns a session of db that only sees the tenant's rows, or db itself for an empty id.
+func DB(db *gorm.DB, id string) *gorm.DB {
+	if id == "" {
+		return db
+	}
+	return db.Scopes(Scope(id)).Session(&gorm.Session{})
+}
+
+// OrDefault returns id, or Default when it is empty.
+func OrDefault(id string) string {
+	if id == "" {
+		return Default
+	}
+	return id
+}
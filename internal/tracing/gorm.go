@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin creates a client span for every query made with a context, see gorm.DB.WithContext.
+// The spans carry the SQL with its placeholders, never the values of the parameters.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		name   string
+		before func(string, func(*gorm.DB)) error
+		after  func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"query", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, proc := range processors {
+		if err := proc.before("tracing:before_"+proc.name, startQuery(proc.name)); err != nil {
+			return err
+		}
+		if err := proc.after("tracing:after_"+proc.name, endQuery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		if ctx == nil || !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			// no trace to attach the query to, e.g. a background job
+			return
+		}
+		name := "db." + operation
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		_, span := Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.sql.table", db.Statement.Table),
+		))
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endQuery(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span := v.(trace.Span)
+	span.SetAttributes(
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	err := db.Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = nil
+	}
+	End(span, err)
+}
@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"REST-service-sub/internal/config"
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+)
+
+// ServiceName identifies the service in the traces.
+const ServiceName = "REST-service-sub"
+
+var tracer = otel.Tracer(ServiceName)
+
+// Init installs the global tracer provider and the W3C trace context propagator. TRACING_EXPORTER
+// is otlp (to OTEL_EXPORTER_OTLP_ENDPOINT, see the OpenTelemetry environment variables), stdout
+// or none; TRACING_SAMPLE_RATIO is the fraction of new traces sampled, a sampled parent is always
+// followed. The returned function flushes the spans and has to be called on shutdown.
+func Init(ctx context.Context, cfg *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.TracingExporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown TRACING_EXPORTER %q", cfg.TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts a span, a child of the span in ctx if any.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, opts...)
+}
+
+// End ends the span, recording err if the operation failed.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
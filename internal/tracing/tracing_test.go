@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"testing"
+)
+
+type subscription struct {
+	ID          uuid.UUID
+	ServiceName string
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	global := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(global)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	// without a trace the query is not traced
+	db.Where("service_name = ?", "Netflix").Find(&[]subscription{})
+	assert.Empty(t, recorder.Ended())
+
+	ctx, parent := Start(context.Background(), "handler")
+	db.WithContext(ctx).Where("service_name = ?", "Netflix").Find(&[]subscription{})
+	End(parent, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "db.query subscriptions", query.Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range query.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	statement := attrs["db.statement"].AsString()
+	assert.Contains(t, statement, "service_name = $1")
+	assert.NotContains(t, statement, "Netflix", "ожидали SQL без значений параметров")
+}
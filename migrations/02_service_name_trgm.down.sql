@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "subscriptions_service_name_trgm_idx";
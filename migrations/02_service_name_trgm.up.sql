@@ -0,0 +1,9 @@
+-- pg_trgm is optional: without it the service falls back to case-insensitive prefix matching.
+DO $$
+BEGIN
+    CREATE EXTENSION IF NOT EXISTS pg_trgm;
+    CREATE INDEX IF NOT EXISTS "subscriptions_service_name_trgm_idx" ON "subscriptions" USING GIN ("service_name" gin_trgm_ops);
+EXCEPTION WHEN OTHERS THEN
+    RAISE NOTICE 'pg_trgm is not available: %', SQLERRM;
+END
+$$;
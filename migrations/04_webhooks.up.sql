@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS subscription_events (
+        id BIGSERIAL PRIMARY KEY,
+        type TEXT NOT NULL,
+        subscription_id UUID NOT NULL,
+        user_id UUID NOT NULL,
+        payload JSONB NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "subscription_events_subscription_id_idx" ON "subscription_events" ("subscription_id");
+-- a subscription expires only once, even if several replicas sweep at the same time
+CREATE UNIQUE INDEX IF NOT EXISTS "subscription_events_expired_uniq" ON "subscription_events" ("subscription_id") WHERE type = 'subscription.expired';
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        url TEXT NOT NULL,
+        secret TEXT NOT NULL,
+        event_types TEXT[] NOT NULL DEFAULT '{}',
+        active BOOLEAN NOT NULL DEFAULT TRUE,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        endpoint_id UUID NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+        event_id BIGINT NOT NULL REFERENCES subscription_events (id) ON DELETE CASCADE,
+        event_type TEXT NOT NULL,
+        status TEXT NOT NULL DEFAULT 'pending',
+        attempts INTEGER NOT NULL DEFAULT 0,
+        next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+        last_error TEXT,
+        delivered_at TIMESTAMP WITH TIME ZONE,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_endpoint_id_idx" ON "webhook_deliveries" ("endpoint_id", "created_at");
+
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+        id BIGSERIAL PRIMARY KEY,
+        delivery_id UUID NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+        attempt INTEGER NOT NULL,
+        status_code INTEGER,
+        error TEXT,
+        duration_ms BIGINT NOT NULL DEFAULT 0,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_delivery_attempts_delivery_id_idx" ON "webhook_delivery_attempts" ("delivery_id");
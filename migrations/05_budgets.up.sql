@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS budgets (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        user_id UUID NOT NULL,
+        category TEXT,
+        service_name TEXT,
+        monthly_limit INTEGER NOT NULL CHECK (monthly_limit >= 0),
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        CHECK (category IS NULL OR service_name IS NULL)
+);
+
+-- one overall budget per user plus at most one per category and per service
+CREATE UNIQUE INDEX IF NOT EXISTS "budgets_scope_uniq" ON "budgets" ("user_id", COALESCE("category", ''), COALESCE("service_name", ''));
+
+CREATE TABLE IF NOT EXISTS budget_alerts (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        budget_id UUID NOT NULL REFERENCES budgets (id) ON DELETE CASCADE,
+        user_id UUID NOT NULL,
+        period DATE NOT NULL,
+        monthly_limit INTEGER NOT NULL,
+        spent BIGINT NOT NULL,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        UNIQUE (budget_id, period)
+);
+
+CREATE INDEX IF NOT EXISTS "budget_alerts_user_id_idx" ON "budget_alerts" ("user_id", "created_at");
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS reminders_sent (
+        subscription_id UUID NOT NULL,
+        kind TEXT NOT NULL,
+        due_date DATE NOT NULL,
+        user_id UUID NOT NULL,
+        sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        PRIMARY KEY (subscription_id, kind, due_date)
+);
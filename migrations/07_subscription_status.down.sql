@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS subscription_pauses;
+DROP INDEX IF EXISTS "subscriptions_status_idx";
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
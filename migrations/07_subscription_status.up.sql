@@ -0,0 +1,17 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'paused', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS "subscriptions_status_idx" ON "subscriptions" ("status");
+
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+        id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+        subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+        paused_at DATE NOT NULL,
+        resumed_at DATE,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        CHECK (resumed_at IS NULL OR resumed_at >= paused_at)
+);
+
+-- at most one open pause per subscription
+CREATE UNIQUE INDEX IF NOT EXISTS "subscription_pauses_open_uniq" ON "subscription_pauses" ("subscription_id") WHERE resumed_at IS NULL;
+CREATE INDEX IF NOT EXISTS "subscription_pauses_subscription_id_idx" ON "subscription_pauses" ("subscription_id");
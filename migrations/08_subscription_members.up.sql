@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS subscription_members (
+        subscription_id UUID NOT NULL REFERENCES subscriptions (id) ON DELETE CASCADE,
+        user_id UUID NOT NULL,
+        weight INTEGER CHECK (weight > 0),
+        amount INTEGER CHECK (amount >= 0),
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        PRIMARY KEY (subscription_id, user_id),
+        CHECK ((weight IS NULL) <> (amount IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS "subscription_members_user_id_idx" ON "subscription_members" ("user_id");
@@ -0,0 +1,23 @@
+DROP INDEX IF EXISTS "budgets_scope_uniq";
+CREATE UNIQUE INDEX IF NOT EXISTS "budgets_scope_uniq" ON "budgets" ("user_id", COALESCE("category", ''), COALESCE("service_name", ''));
+
+ALTER TABLE services DROP CONSTRAINT IF EXISTS services_pkey;
+ALTER TABLE services ADD PRIMARY KEY (name);
+
+DROP INDEX IF EXISTS "budgets_tenant_id_idx";
+DROP INDEX IF EXISTS "webhook_endpoints_tenant_id_idx";
+DROP INDEX IF EXISTS "subscription_events_tenant_id_idx";
+DROP INDEX IF EXISTS "subscriptions_tenant_id_user_id_idx";
+
+ALTER TABLE subscription_members DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE subscription_pauses DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE reminders_sent DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE budget_alerts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE budgets DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhook_delivery_attempts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE subscription_events DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE services DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,24 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE services ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE subscription_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE webhook_delivery_attempts ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE budgets ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE budget_alerts ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE reminders_sent ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE subscription_pauses ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE subscription_members ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS "subscriptions_tenant_id_user_id_idx" ON "subscriptions" ("tenant_id", "user_id");
+CREATE INDEX IF NOT EXISTS "subscription_events_tenant_id_idx" ON "subscription_events" ("tenant_id", "id");
+CREATE INDEX IF NOT EXISTS "webhook_endpoints_tenant_id_idx" ON "webhook_endpoints" ("tenant_id");
+CREATE INDEX IF NOT EXISTS "budgets_tenant_id_idx" ON "budgets" ("tenant_id", "user_id");
+
+-- catalog entries and budget scopes are unique per tenant
+ALTER TABLE services DROP CONSTRAINT IF EXISTS services_pkey;
+ALTER TABLE services ADD PRIMARY KEY (tenant_id, name);
+
+DROP INDEX IF EXISTS "budgets_scope_uniq";
+CREATE UNIQUE INDEX IF NOT EXISTS "budgets_scope_uniq" ON "budgets" ("tenant_id", "user_id", COALESCE("category", ''), COALESCE("service_name", ''));
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS role_assignments (
+        tenant_id TEXT NOT NULL DEFAULT 'default',
+        user_id UUID NOT NULL,
+        role TEXT NOT NULL CHECK (role IN ('admin', 'finance', 'member', 'auditor')),
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        PRIMARY KEY (tenant_id, user_id, role)
+);
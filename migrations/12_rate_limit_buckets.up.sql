@@ -0,0 +1,8 @@
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+        key TEXT PRIMARY KEY,
+        tokens DOUBLE PRECISION NOT NULL,
+        allowed BOOLEAN NOT NULL,
+        updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS "rate_limit_buckets_updated_at_idx" ON "rate_limit_buckets" ("updated_at");
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+        tenant_id TEXT NOT NULL DEFAULT 'default',
+        client TEXT NOT NULL,
+        key TEXT NOT NULL,
+        fingerprint TEXT NOT NULL,
+        status_code INTEGER,
+        content_type TEXT NOT NULL DEFAULT '',
+        response BYTEA,
+        created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+        expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+        PRIMARY KEY (tenant_id, client, key)
+);
+
+CREATE INDEX IF NOT EXISTS "idempotency_keys_expires_at_idx" ON "idempotency_keys" ("expires_at");
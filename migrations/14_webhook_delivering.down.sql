@@ -0,0 +1,3 @@
+UPDATE webhook_deliveries SET status = 'pending' WHERE status = 'delivering';
+DROP INDEX IF EXISTS "webhook_deliveries_pending_idx";
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE status = 'pending';
@@ -0,0 +1,3 @@
+-- deliveries leased by a worker are marked delivering, an expired lease is claimed again
+DROP INDEX IF EXISTS "webhook_deliveries_pending_idx";
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_pending_idx" ON "webhook_deliveries" ("next_attempt_at") WHERE status IN ('pending', 'delivering');
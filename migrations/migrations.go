@@ -0,0 +1,32 @@
+// Package migrations embeds the SQL migrations applied by golang-migrate, so that the service
+// knows the schema version it needs.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the version of the newest migration, the NN of NN_name.up.sql.
+func Latest() (uint, error) {
+	names, err := fs.Glob(FS, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+	var latest uint
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: invalid version", name)
+		}
+		latest = max(latest, uint(v))
+	}
+	return latest, nil
+}
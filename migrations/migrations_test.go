@@ -0,0 +1,17 @@
+package migrations
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/fs"
+	"testing"
+)
+
+func TestLatest(t *testing.T) {
+	latest, err := Latest()
+	require.NoError(t, err)
+
+	downs, err := fs.Glob(FS, "*.down.sql")
+	require.NoError(t, err)
+	assert.Equal(t, uint(len(downs)), latest, "ожидали по одной миграции на версию")
+}